@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithAdaptiveConcurrency makes FetchResources ignore its maxConc argument and
+// size batches with an AIMD controller shared by all of the client's batches:
+// the limit starts at min, grows by one per window of fast successes and
+// halves on errors or slow responses, staying within [min, max].
+func WithAdaptiveConcurrency(min, max int) Option {
+	return func(c *Client) {
+		c.adaptive = NewAdaptiveLimiter(min, max)
+	}
+}
+
+// slowFactor is how many times the baseline latency a response may take
+// before the AdaptiveLimiter treats it as a sign of overload
+const slowFactor = 2
+
+// baselineWeight is how much each successful call moves the baseline latency
+// toward its own, so the baseline follows the backend rather than being
+// pinned by one unusually fast response
+const baselineWeight = 0.1
+
+// AdaptiveLimiter bounds concurrent work with an additive-increase,
+// multiplicative-decrease limit driven by the latency and outcome of each call.
+// It is safe for concurrent use.
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	min, max int
+	limit    float64
+	inFlight int
+	// baseline is a moving average of successful latencies, the reference
+	// for "fast"
+	baseline time.Duration
+	// changed is closed and replaced whenever a slot may have opened up
+	changed chan struct{}
+}
+
+// NewAdaptiveLimiter creates a limiter starting at min and never exceeding max
+func NewAdaptiveLimiter(min, max int) *AdaptiveLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &AdaptiveLimiter{min: min, max: max, limit: float64(min), changed: make(chan struct{})}
+}
+
+// Limit returns the current concurrency limit
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Acquire waits for a slot under the current limit or for ctx to be done
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees a slot and adjusts the limit from the call's latency and error
+func (l *AdaptiveLimiter) Release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	defer l.notify()
+
+	// A cancelled call says nothing about the backend's capacity
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	slow := err == nil && l.baseline > 0 && latency > slowFactor*l.baseline
+	if err == nil {
+		if l.baseline == 0 {
+			l.baseline = latency
+		} else {
+			l.baseline += time.Duration(baselineWeight * float64(latency-l.baseline))
+		}
+	}
+	if err != nil || slow {
+		l.limit /= 2
+	} else {
+		// Adds one to the limit over a full window of successes
+		l.limit += 1 / l.limit
+	}
+	if l.limit < float64(l.min) {
+		l.limit = float64(l.min)
+	}
+	if l.limit > float64(l.max) {
+		l.limit = float64(l.max)
+	}
+}
+
+// notify wakes Acquire calls waiting for a slot; l.mu must be held
+func (l *AdaptiveLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
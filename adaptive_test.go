@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterGrowsOnFastCalls(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 8)
+	for i := 0; i < 20; i++ {
+		l.Release(10*time.Millisecond, nil)
+	}
+	if got := l.Limit(); got < 4 {
+		t.Errorf("limit is %d after 20 fast calls, want at least 4", got)
+	}
+}
+
+func TestAdaptiveLimiterHalvesOnError(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 8)
+	for i := 0; i < 40; i++ {
+		l.Release(10*time.Millisecond, nil)
+	}
+	before := l.Limit()
+	l.Release(10*time.Millisecond, errors.New("boom"))
+	if got := l.Limit(); got != before/2 {
+		t.Errorf("limit went from %d to %d on an error, want it halved", before, got)
+	}
+}
+
+func TestAdaptiveLimiterBaselineRecoversFromOutlier(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 8)
+	// One unusually fast response shouldn't make normal traffic look slow forever
+	l.Release(time.Millisecond, nil)
+	for i := 0; i < 60; i++ {
+		l.Release(10*time.Millisecond, nil)
+	}
+	if got := l.Limit(); got < 4 {
+		t.Errorf("limit is %d after steady 10ms calls, want the baseline to have caught up", got)
+	}
+}
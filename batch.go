@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dedupeIDs returns ids with repeats removed, keeping first occurrences in order
+func dedupeIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// FailFastError is the cancellation cause of a fail-fast batch, naming the
+// fetch whose failure stopped it
+type FailFastError struct {
+	ID  int
+	Err error
+}
+
+func (e *FailFastError) Error() string {
+	return fmt.Sprintf("batch stopped after ID %d failed: %v", e.ID, e.Err)
+}
+
+func (e *FailFastError) Unwrap() error {
+	return e.Err
+}
+
+// WithFailFast makes FetchResources cancel the rest of a batch as soon as one
+// fetch fails. The cancellation cause is a *FailFastError, retrievable with
+// context.Cause and reported for every fetch it stopped.
+func WithFailFast() Option {
+	return func(c *Client) {
+		c.failFast = true
+	}
+}
+
+// batchOptions controls how fetchConcurrently runs a batch
+type batchOptions struct {
+	// maxConc bounds the calls in flight; 0 or less means unbounded
+	maxConc int
+	// failFast cancels the remaining calls after the first failure
+	failFast bool
+	// limiter, when set, replaces maxConc with a dynamic limit
+	limiter *AdaptiveLimiter
+	// jitter, when set, returns how long each call waits before starting
+	jitter func() time.Duration
+}
+
+// WithStartupJitter makes each worker of a batch or stream wait a random
+// duration in [0, max) before its first attempt, so many clients started
+// together don't hit the backend in lockstep. Default timeouts, from
+// WithTimeout and the like, are likewise lengthened by a random [0, max) per
+// call, so calls started together don't all time out and retry together.
+// Delays are drawn from the source set by WithRand, and the startup delay
+// counts against the call's context.
+func WithStartupJitter(max time.Duration) Option {
+	return func(c *Client) {
+		c.startupJitter = max
+	}
+}
+
+// startDelay returns a random startup delay for a batch or stream worker
+func (c *Client) startDelay() time.Duration {
+	return c.rand.Duration(c.startupJitter)
+}
+
+// batchOptions returns the options for a batch run with at most maxConc calls
+// in flight, as configured on the client
+func (c *Client) batchOptions(maxConc int) batchOptions {
+	opts := batchOptions{maxConc: maxConc, failFast: c.failFast, limiter: c.adaptive}
+	if c.startupJitter > 0 {
+		opts.jitter = c.startDelay
+	}
+	return opts
+}
+
+// fetchConcurrently calls fetch once per distinct ID, as configured by opts.
+// Successful values are returned in input order and failures are keyed by ID.
+// IDs that never started because ctx ended are reported with its cause.
+func fetchConcurrently[T any](ctx context.Context, ids []int, opts batchOptions, fetch func(context.Context, int) (*T, error)) ([]*T, map[int]error) {
+	unique := dedupeIDs(ids)
+	maxConc := opts.maxConc
+	if maxConc <= 0 || maxConc > len(unique) {
+		maxConc = len(unique)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	results := make([]*T, len(unique))
+	errs := make(map[int]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := NewSemaphore(maxConc)
+	acquire := func() error {
+		if opts.limiter != nil {
+			return opts.limiter.Acquire(ctx)
+		}
+		return sem.Acquire(ctx)
+	}
+	release := func(latency time.Duration, err error) {
+		if opts.limiter != nil {
+			opts.limiter.Release(latency, err)
+			return
+		}
+		sem.Release()
+	}
+
+launch:
+	for i, id := range unique {
+		if err := acquire(); err != nil {
+			cause := context.Cause(ctx)
+			mu.Lock()
+			for _, unstarted := range unique[i:] {
+				errs[unstarted] = cause
+			}
+			mu.Unlock()
+			break launch
+		}
+
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+
+			var v *T
+			var err error
+			var latency time.Duration
+			if opts.jitter != nil {
+				err = sleepContext(ctx, opts.jitter())
+			}
+			if err == nil {
+				// Timed after the jitter, which says nothing about the backend
+				start := time.Now()
+				v, err = fetch(ctx, id)
+				latency = time.Since(start)
+			}
+			release(latency, err)
+			if err == nil {
+				results[i] = v
+				return
+			}
+
+			var stopped *FailFastError
+			if cause := context.Cause(ctx); errors.As(cause, &stopped) && !errors.Is(err, cause) {
+				// Stopped by another fetch's failure; say which
+				err = fmt.Errorf("%w: %w", cause, err)
+			} else if opts.failFast {
+				cancel(&FailFastError{ID: id, Err: err})
+			}
+			mu.Lock()
+			errs[id] = err
+			mu.Unlock()
+		}(i, id)
+	}
+
+	// Every fetch honors ctx, so this returns promptly once it's cancelled.
+	// Waiting here rather than racing a separate waiter goroutine against ctx
+	// means no goroutine outlives the call and errs is never read while a
+	// worker may still write to it.
+	wg.Wait()
+
+	values := make([]*T, 0, len(results))
+	for _, v := range results {
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	return values, errs
+}
+
+// FetchResources fetches /{resource}/{id} for each distinct ID with at most maxConc
+// requests in flight, or as many as the adaptive limit allows when
+// WithAdaptiveConcurrency is set. Fetched values are returned in input order
+// and failures are keyed by ID.
+func FetchResources[T any](ctx context.Context, c *Client, resource string, ids []int, maxConc int) ([]*T, map[int]error) {
+	return fetchResources[T](ctx, c, resource, ids, maxConc, "")
+}
+
+// fetchResources is FetchResources for the Client method caller
+func fetchResources[T any](ctx context.Context, c *Client, resource string, ids []int, maxConc int, caller string) ([]*T, map[int]error) {
+	return fetchConcurrently(ctx, ids, c.batchOptions(maxConc), func(ctx context.Context, id int) (*T, error) {
+		res := fetchResult[T](ctx, c, resource, id, caller)
+		return res.Value, res.Err
+	})
+}
+
+// defaultBatchConcurrency bounds batch helpers that don't take a concurrency limit
+const defaultBatchConcurrency = 8
+
+// RetryFailed re-fetches the todos whose IDs failed in a previous batch, such as
+// the error map from FetchResources, with the client's usual retry policy.
+// Feeding each returned error map back in converges on the IDs that keep failing.
+func (c *Client) RetryFailed(ctx context.Context, prev map[int]error) ([]*Todo, map[int]error) {
+	ids := make([]int, 0, len(prev))
+	for id := range prev {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return fetchResources[Todo](ctx, c, "todos", ids, defaultBatchConcurrency, "RetryFailed")
+}
+
+// WithBulkhead caps the calls in flight for one resource type (e.g. "users") at
+// maxConcurrent, so a slow resource can't tie up every worker of a shared
+// client and starve the others. Calls wait for a slot until their context is
+// done, and batch concurrency limits still apply on top. Time spent waiting
+// counts against the call's default timeout and WithMaxTotalDuration.
+func WithBulkhead(resource string, maxConcurrent int) Option {
+	return func(c *Client) {
+		if c.bulkheads == nil {
+			c.bulkheads = make(map[string]*weightedSemaphore)
+		}
+		c.bulkheads[resource] = newWeightedSemaphore(int64(maxConcurrent))
+	}
+}
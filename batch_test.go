@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartupJitterNotCountedAsLatency(t *testing.T) {
+	limiter := NewAdaptiveLimiter(1, 4)
+	var calls atomic.Int32
+	opts := batchOptions{
+		limiter: limiter,
+		jitter: func() time.Duration {
+			// The first call starts at once and sets the latency baseline
+			if calls.Add(1) == 1 {
+				return 0
+			}
+			return 40 * time.Millisecond
+		},
+	}
+	_, errs := fetchConcurrently(context.Background(), []int{1, 2, 3, 4, 5, 6}, opts, func(ctx context.Context, id int) (*int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return &id, nil
+	})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got := limiter.Limit(); got < 2 {
+		t.Errorf("limit is %d after only fast fetches, want it to have grown", got)
+	}
+}
+
+func TestStartupJitterLengthensDefaultTimeout(t *testing.T) {
+	const timeout, jitter = time.Second, 500 * time.Millisecond
+	c, err := NewClient(WithTimeout(timeout), WithStartupJitter(jitter), WithRand(rand.New(rand.NewSource(1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	varied := false
+	var first time.Duration
+	for i := 0; i < 10; i++ {
+		before := time.Now()
+		ctx, cancel := c.withDefaultTimeout(context.Background(), apiRequest{resource: "todos"})
+		deadline, _ := ctx.Deadline()
+		cancel()
+		d := deadline.Sub(before)
+		if d < timeout || d > timeout+jitter+50*time.Millisecond {
+			t.Fatalf("timeout %v outside [%v, %v)", d, timeout, timeout+jitter)
+		}
+		if i == 0 {
+			first = d
+		} else if (d - first).Abs() > 10*time.Millisecond {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Error("every default timeout was the same; want jitter")
+	}
+}
+
+func TestBulkheadWaitCountsAgainstTimeouts(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opt  Option
+	}{
+		{"default timeout", WithTimeout(100 * time.Millisecond)},
+		{"max total duration", WithMaxTotalDuration(100 * time.Millisecond)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			arrived := make(chan struct{}, 2)
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				arrived <- struct{}{}
+				<-r.Context().Done()
+			}, WithBulkhead("todos", 1), tt.opt)
+
+			// The first call holds the only slot until it times out
+			go c.FetchTodo(context.Background(), 1)
+			<-arrived
+			start := time.Now()
+			_, err := c.FetchTodo(context.Background(), 2)
+			if took := time.Since(start); took > 150*time.Millisecond {
+				t.Errorf("queued call took %v, want it bounded by the 100ms limit", took)
+			}
+			if err == nil {
+				t.Error("queued call succeeded against a hung server")
+			}
+		})
+	}
+}
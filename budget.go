@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// unknownBodySize is what a response without a Content-Length is assumed to
+// need from the WithMaxInFlightBytes budget
+const unknownBodySize = 64 << 10
+
+// WithMaxInFlightBytes bounds the response bytes all in-flight requests may be
+// reading at once to n. Before a response body is read, its Content-Length is
+// reserved from the budget, waiting while the budget is exhausted; bodies with
+// no Content-Length reserve 64 KiB, and bodies larger than n reserve all of it
+// so they can still be read, one at a time. The reservation is returned once
+// the body has been decoded.
+func WithMaxInFlightBytes(n int64) Option {
+	return func(c *Client) {
+		c.bodyBudget = newWeightedSemaphore(n)
+	}
+}
+
+// reserveBody takes resp's expected size from the body budget, returning the
+// function that gives it back
+func (c *Client) reserveBody(ctx context.Context, resp *http.Response) (func(), error) {
+	if c.bodyBudget == nil {
+		return func() {}, nil
+	}
+	n := resp.ContentLength
+	if n < 0 {
+		n = unknownBodySize
+	}
+	n = min(n, c.bodyBudget.size)
+	if err := c.bodyBudget.acquire(ctx, n); err != nil {
+		return nil, fmt.Errorf("waiting for response body budget: %w", err)
+	}
+	return func() { c.bodyBudget.release(n) }, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBodyBudgetWaitIsNotAStall(t *testing.T) {
+	c := newTestClient(t, todoHandler,
+		WithMaxInFlightBytes(1024),
+		WithReadStallTimeout(20*time.Millisecond),
+	)
+	// Hold the whole budget for several stall timeouts
+	if err := c.bodyBudget.acquire(context.Background(), 1024); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { c.bodyBudget.release(1024) })
+
+	if _, err := c.FetchTodo(context.Background(), 1); err != nil {
+		t.Errorf("FetchTodo after waiting for budget: %v", err)
+	}
+}
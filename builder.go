@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validate reports whether t could be sent to the API as a todo
+func (t Todo) Validate() error {
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("todo title is required")
+	}
+	if t.UserID < 0 {
+		return errors.New("todo user ID must not be negative")
+	}
+	if t.ID < 0 {
+		return errors.New("todo ID must not be negative")
+	}
+	return nil
+}
+
+// TodoBuilder assembles a Todo with chainable setters
+type TodoBuilder struct {
+	todo Todo
+}
+
+// NewTodoBuilder starts a builder from an empty todo
+func NewTodoBuilder() *TodoBuilder {
+	return &TodoBuilder{}
+}
+
+// WithTitle sets the todo's title
+func (b *TodoBuilder) WithTitle(title string) *TodoBuilder {
+	b.todo.Title = title
+	return b
+}
+
+// WithUserID sets the todo's owner
+func (b *TodoBuilder) WithUserID(userID int) *TodoBuilder {
+	b.todo.UserID = userID
+	return b
+}
+
+// WithCompleted sets whether the todo is done
+func (b *TodoBuilder) WithCompleted(completed bool) *TodoBuilder {
+	b.todo.Completed = completed
+	return b
+}
+
+// Build returns the assembled todo, or an error if it fails Validate
+func (b *TodoBuilder) Build() (Todo, error) {
+	if err := b.todo.Validate(); err != nil {
+		return Todo{}, err
+	}
+	return b.todo, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithDiskCache caches successful GET and HEAD responses as files in dir, keyed
+// by a hash of method, URL and body, and serves them until they are older than
+// ttl, marked with an X-Cache: HIT header. Other methods always reach the
+// server, since they change state on it.
+// Entries are written to a temporary file and renamed into place, so
+// concurrent clients never read a partially written entry.
+func WithDiskCache(dir string, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.transports = append(c.transports, func(next http.RoundTripper) http.RoundTripper {
+			return &diskCacheTransport{next: next, dir: dir, ttl: ttl}
+		})
+	}
+}
+
+// diskCacheTransport serves fresh cached responses and caches new 2xx responses
+// to GET and HEAD requests
+type diskCacheTransport struct {
+	next http.RoundTripper
+	dir  string
+	ttl  time.Duration
+}
+
+func (t *diskCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("disk cache: error reading request body: %w", err)
+	}
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String() + "\n" + string(reqBody)))
+	path := filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+
+	if entry, ok := t.load(path); ok {
+		resp := entry.response(req)
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		resp.Header.Set(cacheHeader, "HIT")
+		return resp, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("disk cache: error reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// A cache that can't be written only costs a refetch next time
+	_ = t.store(path, Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestBody:    reqBody,
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		ResponseBody:   respBody,
+	})
+	return resp, nil
+}
+
+// load returns the entry at path if it exists and hasn't expired
+func (t *diskCacheTransport) load(path string) (*Interaction, bool) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > t.ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry Interaction
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// store atomically writes entry to path
+func (t *diskCacheTransport) store(path string, entry Interaction) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
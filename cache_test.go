@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheServesRepeatedGets(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, `{"id":1,"title":"cached"}`)
+	}, WithDiskCache(t.TempDir(), time.Minute))
+
+	var meta ResponseMeta
+	for i := 0; i < 2; i++ {
+		var err error
+		if _, meta, err = FetchResourceWithMeta[Todo](context.Background(), c, "todos", 1); err != nil {
+			t.Fatalf("fetch %d: %v", i, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server got %d requests, want 1", calls.Load())
+	}
+	if !meta.FromCache {
+		t.Error("second fetch wasn't marked as served from cache")
+	}
+}
+
+func TestDiskCacheBypassesWrites(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeJSON(w, `{"id":201,"title":"created"}`)
+	}, WithDiskCache(t.TempDir(), time.Minute))
+
+	todo := Todo{UserID: 1, Title: "created"}
+	for i := 0; i < 2; i++ {
+		if _, err := c.CreateTodo(context.Background(), todo); err != nil {
+			t.Fatalf("CreateTodo %d: %v", i, err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server got %d requests, want 2", calls.Load())
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CancelReason classifies why a request's context ended
+type CancelReason int
+
+const (
+	// ReasonDeadlineExceeded means a deadline or timeout passed
+	ReasonDeadlineExceeded CancelReason = iota + 1
+	// ReasonCancelled means the context was cancelled without a specific cause
+	ReasonCancelled
+	// ReasonParentClosed means an enclosing scope shut the context down with its
+	// own cause, e.g. via context.WithCancelCause
+	ReasonParentClosed
+)
+
+func (r CancelReason) String() string {
+	switch r {
+	case ReasonDeadlineExceeded:
+		return "deadline exceeded"
+	case ReasonCancelled:
+		return "cancelled"
+	case ReasonParentClosed:
+		return "parent closed"
+	default:
+		return fmt.Sprintf("CancelReason(%d)", int(r))
+	}
+}
+
+// CancellationError is returned when a request stops because its context ended.
+// It matches both the request's own error and the context's cause with errors.Is.
+type CancellationError struct {
+	Reason CancelReason
+	// Cause is context.Cause of the request's context
+	Cause error
+	// Err is the error the request failed with
+	Err error
+}
+
+func (e *CancellationError) Error() string {
+	if errors.Is(e.Err, e.Cause) {
+		return fmt.Sprintf("request %s: %v", e.Reason, e.Err)
+	}
+	return fmt.Sprintf("request %s (%v): %v", e.Reason, e.Cause, e.Err)
+}
+
+func (e *CancellationError) Unwrap() []error {
+	return []error{e.Err, e.Cause}
+}
+
+// newCancellationError wraps err, which occurred after ctx ended, with the reason ctx ended.
+// An err that is already a CancellationError is returned as is.
+func newCancellationError(ctx context.Context, err error) error {
+	var cancelErr *CancellationError
+	if errors.As(err, &cancelErr) {
+		return err
+	}
+	cause := context.Cause(ctx)
+	reason := ReasonParentClosed
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		reason = ReasonDeadlineExceeded
+	case cause == context.Canceled:
+		reason = ReasonCancelled
+	}
+	return &CancellationError{Reason: reason, Cause: cause, Err: err}
+}
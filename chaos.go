@@ -0,0 +1,20 @@
+package main
+
+// WithFailureInjection is for testing only: it fails the given fraction (0 to
+// 1) of attempts with err before they reach the network, so callers can
+// exercise their retry and error handling. Each attempt is chosen using the
+// client's random source, so seeding it with WithRand makes the failures
+// reproducible. Whether an injected failure is retried is up to the error
+// classifier, so pass a *url.Error or *HTTPStatusError to mimic a real one.
+// It is off unless set, and it must never be enabled in production.
+func WithFailureInjection(rate float64, err error) Option {
+	return func(c *Client) {
+		c.faultRate = rate
+		c.faultErr = err
+	}
+}
+
+// injectFault decides whether the next attempt fails with the injected error
+func (c *Client) injectFault() bool {
+	return c.faultRate > 0 && c.rand.Float64() < c.faultRate
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a response body doesn't match the
+// checksum its headers declare, with WithVerifyChecksum enabled
+var ErrChecksumMismatch = errors.New("response checksum mismatch")
+
+// WithVerifyChecksum checks each decoded response body against its Content-MD5
+// or Digest header, failing the call with ErrChecksumMismatch when they differ.
+// Digest may use SHA-256 or MD5, and SHA-256 is preferred when both are sent.
+// The body is hashed as it's decoded and compared once decoding finishes.
+// Responses without either header, or that the transport transparently
+// decompressed, so no longer match a digest of the encoded body, are accepted
+// unchecked.
+func WithVerifyChecksum() Option {
+	return func(c *Client) {
+		c.verifyChecksum = true
+	}
+}
+
+// digestAlgorithms are the supported Digest header algorithms, named in
+// lowercase, in order of preference
+var digestAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha-256", sha256.New},
+	{"md5", md5.New},
+}
+
+// expectedChecksum picks the checksum to verify resp against. It returns a nil
+// hash when resp declares none that can be checked.
+func expectedChecksum(resp *http.Response) (hash.Hash, []byte, error) {
+	if resp.Uncompressed {
+		return nil, nil, nil
+	}
+	if digest := resp.Header.Get("Digest"); digest != "" {
+		// e.g. "SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=,MD5=..."
+		values := make(map[string]string)
+		for _, part := range strings.Split(digest, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if ok {
+				values[strings.ToLower(name)] = value
+			}
+		}
+		for _, alg := range digestAlgorithms {
+			if value, ok := values[alg.name]; ok {
+				sum, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%w: malformed Digest %s value: %w", ErrChecksumMismatch, alg.name, err)
+				}
+				return alg.new(), sum, nil
+			}
+		}
+	}
+	if value := resp.Header.Get("Content-MD5"); value != "" {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: malformed Content-MD5: %w", ErrChecksumMismatch, err)
+		}
+		return md5.New(), sum, nil
+	}
+	return nil, nil, nil
+}
+
+// checksumBody tees resp.Body into the hash its headers call for. The returned
+// verify, nil when there's nothing to check, reads what the decoder left of the
+// body and compares the result.
+func checksumBody(resp *http.Response) (verify func() error, err error) {
+	h, want, err := expectedChecksum(resp)
+	if err != nil || h == nil {
+		return nil, err
+	}
+	body := io.TeeReader(resp.Body, h)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+	return func() error {
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return fmt.Errorf("error reading response: %w", err)
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			return fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch,
+				base64.StdEncoding.EncodeToString(got), base64.StdEncoding.EncodeToString(want))
+		}
+		return nil
+	}, nil
+}
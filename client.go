@@ -0,0 +1,880 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultBaseURL is the API the examples talk to
+const defaultBaseURL = "https://jsonplaceholder.typicode.com"
+
+// defaultTimeout bounds requests whose context carries no deadline
+const defaultTimeout = 10 * time.Second
+
+// Client fetches resources from a JSONPlaceholder-style REST API
+type Client struct {
+	baseURL          string
+	fallbackBaseURLs []string
+	urlResolver      func(ctx context.Context) (string, bool)
+	defaultQuery     url.Values
+	apiVersion       string
+	httpClient       *http.Client
+	timeout          time.Duration
+	timeoutEnv       string
+	resourceTimeouts map[string]time.Duration
+	methodTimeouts   map[string]time.Duration
+	bulkheads        map[string]*weightedSemaphore
+	bodyBudget       *weightedSemaphore
+	requireDeadline  bool
+	transports       []func(http.RoundTripper) http.RoundTripper
+	transportConfig  []func(*http.Transport)
+	wireRedact       []string
+	recordBodyLimit  int64
+	dialer           *net.Dialer
+	beforeRequest    []func(*http.Request)
+	signer           func(*http.Request) error
+	contextHeaders   []contextHeader
+	deadlineHeader   bool
+	language         string
+	headerAllowlist  map[string]bool
+	afterResponse    []func(*http.Response, error)
+	upsertFallback   bool
+	jsonPatch        bool
+	idempotencyKeys  bool
+	defaultUserID    int
+	defaultCompleted bool
+	compressRequests bool
+	bodyEnricher     func(ctx context.Context, body map[string]any)
+	streamBuffer     int
+	streamDedupe     *streamDeduper
+	sendTimeout      time.Duration
+	onStalled        StreamStallPolicy
+	useNumber        bool
+	failFast         bool
+	adaptive         *AdaptiveLimiter
+	startupJitter    time.Duration
+	logSampleRate    float64
+	rand             *lockedRand
+	logger           Logger
+	errorBodyLimit   int
+	schemaValidator  SchemaValidator
+	schemaErr        error
+	minLatency       time.Duration
+	stallTimeout     time.Duration
+	retry            RetryPolicy
+	retryByStatus    map[int]int
+	errorClassifier  ErrorClassifier
+	transformers     map[reflect.Type]any
+	transformChain   []func(*Todo) (*Todo, error)
+	responseHooks    map[reflect.Type]any
+	tap              func(ctx context.Context, t *Todo)
+	tapQueue         chan tapEvent
+	tapStop          chan struct{}
+	onRetry          func(attempt int, err error, nextDelay time.Duration)
+	maxTotalDuration time.Duration
+	shutdownGrace    time.Duration
+	latencies        *LatencyTracker
+	slowThreshold    time.Duration
+	onSlow           func(url string, took time.Duration)
+	sizeRecorder     SizeRecorder
+	faultRate        float64
+	faultErr         error
+	verifyChecksum   bool
+	deadlineGrace    time.Duration
+	operations       map[string]openAPIOperation
+	specErr          error
+
+	// baseCtx is the parent of every request; cancelling it aborts them all.
+	// It and baseCancel are replaced by CancelAll, so are guarded by mu.
+	baseCtx    context.Context
+	baseCancel context.CancelCauseFunc
+	mu         sync.Mutex
+	closed     bool
+	active     sync.WaitGroup
+	inFlight   atomic.Int64
+
+	connsReused atomic.Int64
+	connsFresh  atomic.Int64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// NewClient creates a Client with the given options applied. It returns an
+// error wrapping ErrInvalidConfig, naming each offending option, when the
+// configuration is unusable.
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{
+		baseURL:          defaultBaseURL,
+		httpClient:       &http.Client{},
+		timeout:          defaultTimeout,
+		resourceTimeouts: make(map[string]time.Duration),
+		logger:           nopLogger{},
+		streamBuffer:     defaultStreamBuffer,
+		latencies:        NewLatencyTracker(latencyWindow),
+		logSampleRate:    1,
+		rand:             newLockedRand(rand.New(rand.NewSource(time.Now().UnixNano()))),
+	}
+	c.baseCtx, c.baseCancel = context.WithCancelCause(context.Background())
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.applyTimeoutEnv()
+	if err := c.validate(); err != nil {
+		c.baseCancel(err)
+		return nil, err
+	}
+	c.buildTransport()
+	c.startResponseTap()
+	return c, nil
+}
+
+// WithBaseURL sets the base URL requests are sent to
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithFallbackBaseURLs sets mirrors to try, in order, when a request to the base
+// URL still fails with a network error or 5xx after retries. The base URL that
+// served each response is logged.
+func WithFallbackBaseURLs(baseURLs []string) Option {
+	return func(c *Client) {
+		c.fallbackBaseURLs = make([]string, 0, len(baseURLs))
+		for _, u := range baseURLs {
+			c.fallbackBaseURLs = append(c.fallbackBaseURLs, strings.TrimRight(u, "/"))
+		}
+	}
+}
+
+// WithContextURLResolver lets the base URL be chosen per request from its context,
+// e.g. to route each tenant to its own backend. When resolve returns ok=false
+// the static base URL is used.
+func WithContextURLResolver(resolve func(ctx context.Context) (baseURL string, ok bool)) Option {
+	return func(c *Client) {
+		c.urlResolver = resolve
+	}
+}
+
+// WithDefaultQuery adds query parameters to every request URL. Parameters the
+// call sets itself take precedence over defaults with the same name.
+func WithDefaultQuery(query url.Values) Option {
+	return func(c *Client) {
+		c.defaultQuery = make(url.Values, len(query))
+		for key, values := range query {
+			c.defaultQuery[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithAPIVersion inserts version as a path segment between the base URL and
+// every request path, e.g. "v1" turns /todos/1 into /v1/todos/1. An empty
+// version adds no segment.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = strings.Trim(version, "/")
+	}
+}
+
+// requestURL joins baseURL, the API version and path, which may carry its own
+// query, and merges in the default query parameters
+func (c *Client) requestURL(baseURL, path string) string {
+	rawURL := baseURL + path
+	if c.apiVersion != "" {
+		rawURL = baseURL + "/" + c.apiVersion + path
+	}
+	if len(c.defaultQuery) == 0 {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// Let http.NewRequest report the malformed URL
+		return rawURL
+	}
+	q := u.Query()
+	for key, values := range c.defaultQuery {
+		if _, ok := q[key]; !ok {
+			q[key] = values
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// resolveBaseURL returns the base URL for a request made with ctx
+func (c *Client) resolveBaseURL(ctx context.Context) string {
+	if c.urlResolver != nil {
+		if baseURL, ok := c.urlResolver(ctx); ok {
+			return strings.TrimRight(baseURL, "/")
+		}
+	}
+	return c.baseURL
+}
+
+// WithHTTPClient sets the HTTP client used to make requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout sets the default timeout applied when the context has no deadline
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithResourceTimeout sets the default timeout for one resource type (e.g. "users"),
+// taking precedence over WithTimeout when the context has no deadline
+func WithResourceTimeout(resource string, d time.Duration) Option {
+	return func(c *Client) {
+		c.resourceTimeouts[resource] = d
+	}
+}
+
+// ErrNotFound matches errors for resources the server reported as missing
+var ErrNotFound = errors.New("not found")
+
+// defaultErrorBodyCapture is how much of an error response WithErrorBodyCapture keeps by default
+const defaultErrorBodyCapture = 1024
+
+// WithErrorBodyCapture attaches up to maxBytes of non-2xx response bodies to the
+// returned HTTPStatusError. A maxBytes of 0 or less captures 1 KiB.
+// Successful responses are unaffected.
+func WithErrorBodyCapture(maxBytes int) Option {
+	return func(c *Client) {
+		if maxBytes <= 0 {
+			maxBytes = defaultErrorBodyCapture
+		}
+		c.errorBodyLimit = maxBytes
+	}
+}
+
+// WithBeforeRequest registers fn to inspect or modify every request just before it
+// is sent, including each retry attempt
+func WithBeforeRequest(fn func(*http.Request)) Option {
+	return func(c *Client) {
+		c.beforeRequest = append(c.beforeRequest, fn)
+	}
+}
+
+// WithRequestSigner calls sign on every attempt right before it is sent, after
+// all other headers are set, so it can attach a signature such as an HMAC of
+// the method, path and body. The body can be read freely: it is restored
+// before sending. Signing failures are returned without sending the attempt.
+func WithRequestSigner(sign func(*http.Request) error) Option {
+	return func(c *Client) {
+		c.signer = sign
+	}
+}
+
+// WithAfterResponse registers fn to observe the outcome of every request. When
+// sending fails fn receives a nil response and the error. fn must not close
+// or consume the response body.
+func WithAfterResponse(fn func(*http.Response, error)) Option {
+	return func(c *Client) {
+		c.afterResponse = append(c.afterResponse, fn)
+	}
+}
+
+// WithMinLatency makes every request take at least d, padding fast responses
+// after the HTTP call returns. Useful for simulating slow backends.
+func WithMinLatency(d time.Duration) Option {
+	return func(c *Client) {
+		c.minLatency = d
+	}
+}
+
+// CallOption configures a single method call
+type CallOption func(*callOptions)
+
+// callOptions holds the settings for one call
+type callOptions struct {
+	idempotencyKey string
+	retry          *RetryPolicy
+	completed      *bool
+	// caller is the Client method the call was made through
+	caller string
+}
+
+// newCallOptions applies opts to a fresh callOptions for a call made through
+// the Client method caller, or "" for package-level functions
+func newCallOptions(caller string, opts []CallOption) callOptions {
+	o := callOptions{caller: caller}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// HTTPStatusError is returned when the server responds with a non-2xx status
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	// Body holds the start of the response body when WithErrorBodyCapture is set
+	Body []byte
+	// RetryAfter is the wait the server asked for in a Retry-After header, or 0
+	RetryAfter time.Duration
+
+	// resp is the response for the error classifier; its Body is closed
+	resp *http.Response
+}
+
+func (e *HTTPStatusError) Error() string {
+	if len(e.Body) > 0 {
+		return fmt.Sprintf("unexpected status: %s: %s", e.Status, e.Body)
+	}
+	return fmt.Sprintf("unexpected status: %s", e.Status)
+}
+
+// Is reports a 404 as ErrNotFound so callers can use errors.Is
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// ErrUnexpectedContentType is returned when a response that should be JSON has
+// another media type, typically an HTML error page from a gateway or portal
+type ErrUnexpectedContentType struct {
+	Got string
+}
+
+func (e *ErrUnexpectedContentType) Error() string {
+	return fmt.Sprintf("unexpected content type %q, want application/json", e.Got)
+}
+
+// checkContentType accepts application/json and +json media types with any
+// parameters, as well as a missing Content-Type
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return &ErrUnexpectedContentType{Got: contentType}
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return &ErrUnexpectedContentType{Got: contentType}
+	}
+	return nil
+}
+
+// ErrNoDeadline is returned when WithRequireDeadline is set and a request's
+// context has no deadline
+var ErrNoDeadline = errors.New("context has no deadline")
+
+// WithRequireDeadline makes every request fail with ErrNoDeadline unless its
+// context has a deadline, rather than falling back to the default timeout
+func WithRequireDeadline() Option {
+	return func(c *Client) {
+		c.requireDeadline = true
+	}
+}
+
+// timeoutFor returns the default timeout for r's method and resource
+func (c *Client) timeoutFor(r apiRequest) time.Duration {
+	if d, ok := c.methodTimeouts[r.caller]; ok && r.caller != "" {
+		return d
+	}
+	if d, ok := c.resourceTimeouts[r.resource]; ok {
+		return d
+	}
+	return c.timeout
+}
+
+// withDefaultTimeout bounds ctx by r's default timeout, plus startup jitter,
+// unless it already has a deadline
+func (c *Client) withDefaultTimeout(ctx context.Context, r apiRequest) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	d := c.timeoutFor(r)
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d+c.startDelay())
+}
+
+// apiRequest describes one logical call to the API
+type apiRequest struct {
+	method   string
+	resource string
+	path     string
+	// id is the record being addressed, or 0 for collection requests
+	id  int
+	in  any
+	out any
+	// decode, when set, reads the response body in place of decoding into out
+	decode func(io.Reader) error
+	// onHeader, when set, receives the headers of a successful response
+	onHeader func(http.Header)
+	// header holds extra headers sent on every attempt
+	header http.Header
+	// contentType replaces application/json as the type of the request body
+	contentType string
+	// attempts, when set, is incremented for every attempt made
+	attempts *int
+	// retry, when set, replaces the client's retry settings for this call
+	retry *RetryPolicy
+	// noFallback keeps the call on the primary base URL
+	noFallback bool
+	// meta, when set, describes the response to the latest attempt
+	meta *ResponseMeta
+	// caller is the Client method the call was made through, for WithMethodTimeout
+	caller string
+
+	// The remaining fields are set by do for the whole call: the encoded
+	// request body and its Content-Encoding, when and whether to log
+	body         []byte
+	bodyEncoding string
+	start        time.Time
+	logged       bool
+}
+
+// requestLogger returns a child logger scoped to r and the given attempt
+func (c *Client) requestLogger(r apiRequest, attempt int) Logger {
+	keyvals := []any{"method", r.method, "resource", r.resource}
+	if r.id != 0 {
+		keyvals = append(keyvals, "id", r.id)
+	}
+	keyvals = append(keyvals, "attempt", attempt)
+	return c.logger.With(keyvals...)
+}
+
+// do sends r, JSON-encoding r.in as the body when non-nil and decoding the JSON
+// response into r.out when non-nil. Failed attempts are retried per the retry
+// policy, then repeated against any fallback base URLs. Failures caused by the
+// context ending are returned as a *CancellationError.
+func (c *Client) do(ctx context.Context, r apiRequest) error {
+	if _, ok := ctx.Deadline(); c.requireDeadline && !ok {
+		return ErrNoDeadline
+	}
+	ctx, done, err := c.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := c.withDefaultTimeout(ctx, r)
+	defer cancel()
+	// The grace deadline brings forward whichever deadline binds, so it
+	// reports the cap when that's the one it shortened
+	var graceCause error
+	if c.maxTotalDuration > 0 {
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > c.maxTotalDuration {
+			graceCause = ErrMaxDurationExceeded
+		}
+		var cancelTotal context.CancelFunc
+		ctx, cancelTotal = context.WithTimeoutCause(ctx, c.maxTotalDuration, ErrMaxDurationExceeded)
+		defer cancelTotal()
+	}
+	ctx, cancelGrace := c.withDeadlineGrace(ctx, graceCause)
+	defer cancelGrace()
+	// Queueing for the bulkhead counts against the timeouts above
+	if bulkhead := c.bulkheads[r.resource]; bulkhead != nil {
+		if err := bulkhead.acquire(ctx, 1); err != nil {
+			return fmt.Errorf("waiting for %s bulkhead: %w", r.resource, newCancellationError(ctx, err))
+		}
+		defer bulkhead.release(1)
+	}
+
+	if r.in != nil {
+		// Encode once so every attempt sends identical bytes
+		if r.body, r.bodyEncoding, err = c.encodeBody(ctx, r.in); err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+	}
+	if r.retry != nil {
+		ctx = context.WithValue(ctx, callRetryKey{}, *r.retry)
+	}
+	r.start = time.Now()
+	r.logged = c.sampleLog()
+	baseURL := c.resolveBaseURL(ctx)
+	err = c.doAttempts(ctx, r, baseURL)
+	fallbacks := c.fallbackBaseURLs
+	if r.noFallback {
+		fallbacks = nil
+	}
+	for _, fallback := range fallbacks {
+		if err == nil || !c.isRetryable(ctx, err) {
+			break
+		}
+		c.requestLogger(r, 1).Log("trying fallback base URL", "baseURL", fallback, "error", err)
+		baseURL = fallback
+		err = c.doAttempts(ctx, r, baseURL)
+	}
+	took := time.Since(r.start)
+	c.latencies.Record(took)
+	if c.onSlow != nil && took > c.slowThreshold {
+		go c.onSlow(c.requestURL(baseURL, r.path), took)
+	}
+	if err != nil && context.Cause(ctx) == ErrMaxDurationExceeded {
+		return fmt.Errorf("%w (%v): %w", ErrMaxDurationExceeded, c.maxTotalDuration, err)
+	}
+	if err != nil && ctx.Err() != nil {
+		return newCancellationError(ctx, err)
+	}
+	return err
+}
+
+// doAttempts sends r to baseURL, retrying failed attempts per the retry policy
+func (c *Client) doAttempts(ctx context.Context, r apiRequest, baseURL string) error {
+	for attempt := 1; ; attempt++ {
+		if r.attempts != nil {
+			*r.attempts++
+		}
+		logger := c.requestLogger(r, attempt)
+		attemptStart := time.Now()
+		if r.logged {
+			logger.Log("starting request", "path", r.path)
+		}
+
+		err := c.roundTrip(ctx, r, baseURL, logger)
+		if err == nil {
+			err = c.waitMinLatency(ctx, r.start)
+		}
+		if err == nil {
+			if r.logged {
+				logger.Log("request completed", "baseURL", baseURL, "elapsed", time.Since(attemptStart).Round(time.Millisecond))
+			}
+			return nil
+		}
+		// Failures are logged even when the request wasn't sampled
+		logger.Log("request failed", "elapsed", time.Since(attemptStart).Round(time.Millisecond), "error", err)
+
+		if !c.shouldRetry(ctx, r.retry, attempt, err) {
+			return err
+		}
+		policy := c.retry
+		if r.retry != nil {
+			policy = *r.retry
+		}
+		delay := policy.backoff(attempt)
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+		if c.onRetry != nil {
+			c.onRetry(attempt, err, delay)
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("retry cancelled after attempt %d: %w: %w", attempt, sleepErr, err)
+		}
+	}
+}
+
+// waitMinLatency sleeps until the configured minimum latency has passed since start
+func (c *Client) waitMinLatency(ctx context.Context, start time.Time) error {
+	remaining := c.minLatency - time.Since(start)
+	if remaining <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("request cancelled while waiting for minimum latency: %w", ctx.Err())
+	}
+}
+
+// WithJSONNumberMode decodes JSON numbers as json.Number rather than float64
+// wherever the target is an interface, e.g. FetchResource[map[string]any], so
+// large integers and precise decimals survive. Typed fields such as Todo's are
+// decoded as before. It only changes how numbers are represented: unknown
+// fields are still ignored rather than rejected, and a number that doesn't fit
+// a typed field is still an error.
+func WithJSONNumberMode() Option {
+	return func(c *Client) {
+		c.useNumber = true
+	}
+}
+
+// newDecoder returns a JSON decoder for a response body, configured per the client
+func (c *Client) newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if c.useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
+
+// bodyBuffers recycles the buffers response bodies are read into for validation
+var bodyBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBodyBuffer is the largest buffer returned to bodyBuffers, so one huge
+// response doesn't pin its memory for the life of the process
+const maxPooledBodyBuffer = 1 << 20
+
+// readBody reads body into a pooled buffer. The caller must pass the buffer to
+// releaseBody once nothing refers to its bytes.
+func readBody(body io.Reader) (*bytes.Buffer, error) {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if _, err := buf.ReadFrom(body); err != nil {
+		releaseBody(buf)
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	return buf, nil
+}
+
+// releaseBody returns buf to the pool
+func releaseBody(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBodyBuffer {
+		bodyBuffers.Put(buf)
+	}
+}
+
+// validateBody checks a response body against the response schema. Array
+// bodies are checked element by element, holding one element at a time.
+func (c *Client) validateBody(data []byte) error {
+	var violations []string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		for i := 0; dec.More(); i++ {
+			var element json.RawMessage
+			if err := dec.Decode(&element); err != nil {
+				return fmt.Errorf("error decoding response: %w", err)
+			}
+			for _, violation := range c.schemaValidator.Validate(element) {
+				violations = append(violations, fmt.Sprintf("[%d]: %s", i, violation))
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+	} else {
+		violations = c.schemaValidator.Validate(data)
+	}
+	if len(violations) > 0 {
+		return &ErrSchemaValidation{Violations: violations}
+	}
+	return nil
+}
+
+// roundTrip performs a single HTTP exchange for r against baseURL
+func (c *Client) roundTrip(ctx context.Context, r apiRequest, baseURL string, logger Logger) error {
+	var stalled context.CancelCauseFunc
+	if c.stallTimeout > 0 {
+		ctx, stalled = context.WithCancelCause(ctx)
+		defer stalled(nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, c.requestURL(baseURL, r.path), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if r.body != nil {
+		// Each attempt gets a fresh reader over the same encoded bytes, and
+		// GetBody lets the transport replay it on redirects and retried writes
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(r.body)), nil
+		}
+		req.Body, _ = req.GetBody()
+		req.ContentLength = int64(len(r.body))
+	}
+	c.applyLanguage(ctx, req)
+	c.applyContextHeaders(ctx, req, logger)
+	if c.deadlineHeader {
+		applyDeadlineHeader(ctx, req)
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	} else if r.in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.bodyEncoding != "" {
+		req.Header.Set("Content-Encoding", r.bodyEncoding)
+	}
+
+	for _, hook := range c.beforeRequest {
+		hook(req)
+	}
+	if c.signer != nil {
+		if err := c.signer(req); err != nil {
+			return fmt.Errorf("error signing request: %w", err)
+		}
+		if req.GetBody != nil {
+			// The signer may have consumed the body to hash it
+			req.Body, _ = req.GetBody()
+		}
+	}
+	if c.injectFault() {
+		return fmt.Errorf("request failed: %w", c.faultErr)
+	}
+	resp, err := c.httpClient.Do(c.traceConns(req))
+	for _, hook := range c.afterResponse {
+		hook(resp, err)
+	}
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if stalled != nil {
+		resp.Body = newStallReader(ctx, resp.Body, c.stallTimeout, stalled)
+	}
+	if r.meta != nil {
+		*r.meta = newResponseMeta(resp)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			statusErr.RetryAfter = d
+		}
+		if c.errorBodyLimit > 0 {
+			// A failed read just means less context in the error
+			statusErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, int64(c.errorBodyLimit)))
+		}
+		if c.errorClassifier != nil {
+			statusErr.resp = resp
+		}
+		return statusErr
+	}
+
+	if r.onHeader != nil {
+		r.onHeader(resp.Header)
+	}
+	if r.decode == nil && r.out == nil {
+		return nil
+	}
+	release, err := c.reserveBody(ctx, resp)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		return err
+	}
+	if c.schemaValidator != nil {
+		// Read once, then validate and decode from the same bytes
+		buf, err := readBody(resp.Body)
+		if err != nil {
+			return err
+		}
+		defer releaseBody(buf)
+		if err := c.validateBody(buf.Bytes()); err != nil {
+			return err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	}
+	var verify func() error
+	if c.verifyChecksum {
+		if verify, err = checksumBody(resp); err != nil {
+			return err
+		}
+	}
+	var counter *countingReader
+	if c.sizeRecorder != nil {
+		counter = &countingReader{r: resp.Body}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{counter, resp.Body}
+	}
+	var decodeErr error
+	if r.decode != nil {
+		decodeErr = r.decode(resp.Body)
+	} else if err := c.newDecoder(resp.Body).Decode(r.out); err != nil {
+		decodeErr = fmt.Errorf("error decoding response: %w", err)
+	}
+	if verify != nil && decodeErr == nil {
+		decodeErr = verify()
+	}
+	if counter != nil && decodeErr == nil {
+		c.sizeRecorder(r.resource, counter.n)
+	}
+	return decodeErr
+}
+
+// getJSON makes a GET request for path under resource and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, resource, path string, v any) error {
+	return c.do(ctx, apiRequest{method: http.MethodGet, resource: resource, path: path, out: v})
+}
+
+// FetchResource fetches /{resource}/{id} and decodes it into a new T.
+// The resource name selects the default timeout set by WithResourceTimeout.
+func FetchResource[T any](ctx context.Context, c *Client, resource string, id int, opts ...CallOption) (*T, error) {
+	res := fetchResultMeta[T](ctx, c, resource, id, nil, newCallOptions("", opts))
+	return res.Value, res.Err
+}
+
+// FetchTodo fetches a single todo by ID
+func (c *Client) FetchTodo(ctx context.Context, id int, opts ...CallOption) (*Todo, error) {
+	return c.fetchTodo(ctx, id, newCallOptions("FetchTodo", opts))
+}
+
+// fetchTodo is FetchTodo with per-call options
+func (c *Client) fetchTodo(ctx context.Context, id int, o callOptions) (*Todo, error) {
+	res := fetchResultMeta[Todo](ctx, c, "todos", id, nil, o)
+	return res.Value, res.Err
+}
+
+// FetchTodoMust is FetchTodo panicking on error. It is meant for scripts and
+// test setup where any failure should abort; production code should call
+// FetchTodo and handle the error.
+func (c *Client) FetchTodoMust(ctx context.Context, id int) *Todo {
+	todo, err := c.fetchTodo(ctx, id, newCallOptions("FetchTodoMust", nil))
+	if err != nil {
+		panic(fmt.Errorf("FetchTodo(%d): %w", id, err))
+	}
+	return todo
+}
+
+// FetchTodoOrDefault fetches a single todo by ID, returning def instead when the
+// server reports it missing. Any other failure, such as a network, status or
+// decode error, is still returned.
+func (c *Client) FetchTodoOrDefault(ctx context.Context, id int, def Todo) (Todo, error) {
+	todo, err := c.fetchTodo(ctx, id, newCallOptions("FetchTodoOrDefault", nil))
+	if errors.Is(err, ErrNotFound) {
+		return def, nil
+	}
+	if err != nil {
+		return Todo{}, err
+	}
+	return *todo, nil
+}
+
+// FetchTodoWithResult fetches a single todo by ID like FetchTodo, also reporting
+// how many attempts it took and the total latency, e.g. for SLO tracking
+func (c *Client) FetchTodoWithResult(ctx context.Context, id int, opts ...CallOption) Result[Todo] {
+	return fetchResultMeta[Todo](ctx, c, "todos", id, nil, newCallOptions("FetchTodoWithResult", opts))
+}
+
+// Ping checks the backend is reachable by fetching a single todo. It makes a
+// single attempt against the primary base URL, without retries or fallbacks,
+// and returns nil on success and the underlying error otherwise.
+func (c *Client) Ping(ctx context.Context) error {
+	var discard json.RawMessage
+	return c.do(ctx, apiRequest{
+		method:     http.MethodGet,
+		resource:   "todos",
+		path:       "/todos/1",
+		out:        &discard,
+		retry:      &RetryPolicy{MaxAttempts: 1},
+		noFallback: true,
+		caller:     "Ping",
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPingMakesOneAttempt(t *testing.T) {
+	var primary, fallback atomic.Int32
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallback.Add(1)
+		writeJSON(w, `{"id":1}`)
+	}))
+	defer backup.Close()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		primary.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	},
+		WithRetry(RetryPolicy{MaxAttempts: 3}),
+		WithFallbackBaseURLs([]string{backup.URL}),
+	)
+
+	err := c.Ping(context.Background())
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Ping: got %v, want a 503 error", err)
+	}
+	if primary.Load() != 1 || fallback.Load() != 0 {
+		t.Errorf("got %d primary and %d fallback requests, want 1 and 0", primary.Load(), fallback.Load())
+	}
+}
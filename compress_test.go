@@ -0,0 +1,70 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// echoTodoServer decodes each posted todo, gunzipping it when marked, and
+// echoes it back, recording the Content-Encoding it arrived with
+func echoTodoServer(t *testing.T, opts ...Option) (*Client, func() string) {
+	var mu sync.Mutex
+	var encoding string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		encoding = r.Header.Get("Content-Encoding")
+		mu.Unlock()
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+		var todo Todo
+		if err := json.NewDecoder(body).Decode(&todo); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		todo.ID = 201
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(todo)
+	}, opts...)
+	return c, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return encoding
+	}
+}
+
+func TestRequestCompression(t *testing.T) {
+	c, encoding := echoTodoServer(t, WithRequestCompression())
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"large body is gzipped", strings.Repeat("x", 2*minCompressSize), "gzip"},
+		{"tiny body is sent as is", "short", ""},
+	}
+	for _, tt := range tests {
+		created, err := c.CreateTodo(context.Background(), Todo{UserID: 1, Title: tt.title})
+		if err != nil {
+			t.Fatalf("%s: CreateTodo: %v", tt.name, err)
+		}
+		if got := encoding(); got != tt.want {
+			t.Errorf("%s: Content-Encoding %q, want %q", tt.name, got, tt.want)
+		}
+		if created.Title != tt.title {
+			t.Errorf("%s: server decoded a title of %d bytes, want %d", tt.name, len(created.Title), len(tt.title))
+		}
+	}
+}
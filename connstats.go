@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptrace"
+)
+
+// ConnStats returns how many requests were sent over a reused keep-alive
+// connection and how many needed a fresh one, counting every attempt. A low
+// reuse ratio under steady load suggests the keep-alive or idle-pool settings
+// don't suit the workload.
+func (c *Client) ConnStats() (reused, fresh int64) {
+	return c.connsReused.Load(), c.connsFresh.Load()
+}
+
+// traceConns returns req with a trace counting its connection towards ConnStats.
+// Any trace already in req's context still runs.
+func (c *Client) traceConns(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.connsReused.Add(1)
+			} else {
+				c.connsFresh.Add(1)
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WithUpsertFallback makes UpsertTodo create the todo when updating it returns 404
+func WithUpsertFallback() Option {
+	return func(c *Client) {
+		c.upsertFallback = true
+	}
+}
+
+// WithIdempotencyKeys makes CreateTodo send a generated Idempotency-Key header
+// when the call doesn't supply one with WithIdempotencyKey
+func WithIdempotencyKeys() Option {
+	return func(c *Client) {
+		c.idempotencyKeys = true
+	}
+}
+
+// WithIdempotencyKey sends key as the Idempotency-Key header of a create call
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// newIdempotencyKey returns a random version 4 UUID
+func newIdempotencyKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("error generating idempotency key: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// WithBodyEnricher lets fn add fields to, or change, the JSON object body of
+// every create and update call before it is sent, e.g. a "_trace_id" taken
+// from ctx. Array bodies, as sent by CreateTodosBatch, are left alone. A nil
+// fn is a no-op.
+func WithBodyEnricher(fn func(ctx context.Context, body map[string]any)) Option {
+	return func(c *Client) {
+		c.bodyEnricher = fn
+	}
+}
+
+// enrichBody runs the body enricher over the JSON object in data
+func (c *Client) enrichBody(ctx context.Context, data []byte) ([]byte, error) {
+	if c.bodyEnricher == nil || !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers exactly as marshaled
+	dec.UseNumber()
+	var body map[string]any
+	if err := dec.Decode(&body); err != nil {
+		return nil, err
+	}
+	c.bodyEnricher(ctx, body)
+	return json.Marshal(body)
+}
+
+// WithDefaultUserID fills in userID on todos passed to CreateTodo with a zero UserID
+func WithDefaultUserID(userID int) Option {
+	return func(c *Client) {
+		c.defaultUserID = userID
+	}
+}
+
+// WithDefaultCompleted fills in completed on todos passed to CreateTodo whose
+// Completed is false. Since false is also the zero value, a call that means
+// false passes WithExplicitCompleted(false) to keep it.
+func WithDefaultCompleted(completed bool) Option {
+	return func(c *Client) {
+		c.defaultCompleted = completed
+	}
+}
+
+// WithExplicitCompleted makes a create call send completed as the todo's
+// Completed, whatever WithDefaultCompleted says
+func WithExplicitCompleted(completed bool) CallOption {
+	return func(o *callOptions) {
+		o.completed = &completed
+	}
+}
+
+// applyCreateDefaults backfills zero fields of todo with the client's defaults,
+// unless the call set them explicitly
+func (c *Client) applyCreateDefaults(todo Todo, o callOptions) Todo {
+	if todo.UserID == 0 {
+		todo.UserID = c.defaultUserID
+	}
+	switch {
+	case o.completed != nil:
+		todo.Completed = *o.completed
+	case !todo.Completed:
+		todo.Completed = c.defaultCompleted
+	}
+	return todo
+}
+
+// CreateTodo POSTs a new todo and returns it as stored by the server, including its
+// assigned ID. Zero fields are first filled from WithDefaultUserID and
+// WithDefaultCompleted. Any Idempotency-Key is chosen once per call, so retries of the
+// same create reuse it.
+func (c *Client) CreateTodo(ctx context.Context, todo Todo, opts ...CallOption) (*Todo, error) {
+	return c.createTodo(ctx, todo, nil, newCallOptions("CreateTodo", opts))
+}
+
+// createTodo is CreateTodo, describing the response in meta when non-nil
+func (c *Client) createTodo(ctx context.Context, todo Todo, meta *ResponseMeta, o callOptions) (*Todo, error) {
+	todo = c.applyCreateDefaults(todo, o)
+	key := o.idempotencyKey
+	if key == "" && c.idempotencyKeys {
+		var err error
+		if key, err = newIdempotencyKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	var created Todo
+	r := apiRequest{method: http.MethodPost, resource: "todos", path: "/todos", in: todo, out: &created, retry: o.retry, meta: meta, caller: o.caller}
+	if key != "" {
+		r.header = http.Header{"Idempotency-Key": {key}}
+	}
+	if err := c.do(ctx, r); err != nil {
+		return nil, err
+	}
+	return transform(ctx, c, &created)
+}
+
+// UpdateTodo PUTs todo over the existing todo with the same ID
+func (c *Client) UpdateTodo(ctx context.Context, todo Todo, opts ...CallOption) (*Todo, error) {
+	return c.updateTodo(ctx, todo, nil, newCallOptions("UpdateTodo", opts))
+}
+
+// updateTodo is UpdateTodo, describing the response in meta when non-nil
+func (c *Client) updateTodo(ctx context.Context, todo Todo, meta *ResponseMeta, o callOptions) (*Todo, error) {
+	if todo.ID == 0 {
+		return nil, errors.New("update requires a todo ID")
+	}
+	var updated Todo
+	r := apiRequest{
+		method:   http.MethodPut,
+		resource: "todos",
+		path:     fmt.Sprintf("/todos/%d", todo.ID),
+		id:       todo.ID,
+		in:       todo,
+		out:      &updated,
+		retry:    o.retry,
+		meta:     meta,
+		caller:   o.caller,
+	}
+	if err := c.do(ctx, r); err != nil {
+		return nil, err
+	}
+	return transform(ctx, c, &updated)
+}
+
+// UpsertTodo updates todo when it has an ID and creates it otherwise.
+// If the update fails with ErrNotFound and WithUpsertFallback is set, the todo
+// is created instead and the server assigns it a new ID.
+func (c *Client) UpsertTodo(ctx context.Context, todo Todo) (*Todo, error) {
+	return c.upsertTodo(ctx, todo, nil, "UpsertTodo")
+}
+
+// upsertTodo is UpsertTodo for the Client method caller, describing the last
+// response in meta when non-nil
+func (c *Client) upsertTodo(ctx context.Context, todo Todo, meta *ResponseMeta, caller string) (*Todo, error) {
+	o := newCallOptions(caller, nil)
+	if todo.ID == 0 {
+		return c.createTodo(ctx, todo, meta, o)
+	}
+	updated, err := c.updateTodo(ctx, todo, meta, o)
+	if errors.Is(err, ErrNotFound) && c.upsertFallback {
+		todo.ID = 0
+		return c.createTodo(ctx, todo, meta, o)
+	}
+	return updated, err
+}
+
+// CreateTodosBatch creates todos in a single request by POSTing them as one JSON
+// array and decoding the array the server returns. The backend must accept
+// array bodies; unlike separate CreateTodo calls, the batch succeeds or fails
+// as a whole.
+func (c *Client) CreateTodosBatch(ctx context.Context, todos []Todo) ([]Todo, error) {
+	return c.createTodosBatch(ctx, todos, nil, "CreateTodosBatch")
+}
+
+// createTodosBatch is CreateTodosBatch for the Client method caller,
+// describing the response in meta when non-nil
+func (c *Client) createTodosBatch(ctx context.Context, todos []Todo, meta *ResponseMeta, caller string) ([]Todo, error) {
+	batch := make([]Todo, len(todos))
+	for i, todo := range todos {
+		batch[i] = c.applyCreateDefaults(todo, callOptions{})
+	}
+
+	var created []Todo
+	err := c.do(ctx, apiRequest{
+		method:   http.MethodPost,
+		resource: "todos",
+		path:     "/todos",
+		in:       batch,
+		decode: func(body io.Reader) error {
+			return decodeArray(c.newDecoder(body), &created)
+		},
+		meta:   meta,
+		caller: caller,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := transformEach(ctx, c, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCreateTodoRetryResendsBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		first := len(bodies) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, `{"id":201,"userId":1,"title":"write me"}`)
+	}, WithRetry(RetryPolicy{MaxAttempts: 2}))
+
+	if _, err := c.CreateTodo(context.Background(), Todo{UserID: 1, Title: "write me"}); err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("server got %d requests, want 2", len(bodies))
+	}
+	if bodies[0] == "" || bodies[1] != bodies[0] {
+		t.Errorf("retry sent %q after %q, want the same non-empty body", bodies[1], bodies[0])
+	}
+}
+
+func TestCreateTodoDefaults(t *testing.T) {
+	var sent atomic.Pointer[Todo]
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var todo Todo
+		if err := json.NewDecoder(r.Body).Decode(&todo); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		sent.Store(&todo)
+		writeJSON(w, `{"id":201}`)
+	}, WithDefaultUserID(7), WithDefaultCompleted(true))
+
+	tests := []struct {
+		name      string
+		todo      Todo
+		opts      []CallOption
+		userID    int
+		completed bool
+	}{
+		{"zero fields take the defaults", Todo{Title: "a"}, nil, 7, true},
+		{"set fields are kept", Todo{UserID: 3, Completed: true}, nil, 3, true},
+		{"explicit false creates a pending todo", Todo{Title: "pending"}, []CallOption{WithExplicitCompleted(false)}, 7, false},
+		{"explicit true is kept", Todo{}, []CallOption{WithExplicitCompleted(true)}, 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.CreateTodo(context.Background(), tt.todo, tt.opts...); err != nil {
+				t.Fatalf("CreateTodo: %v", err)
+			}
+			got := sent.Load()
+			if got.UserID != tt.userID || got.Completed != tt.completed {
+				t.Errorf("sent userId %d, completed %v; want %d, %v", got.UserID, got.Completed, tt.userID, tt.completed)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// maxStreamDedupeEntries bounds how many recently emitted IDs WithStreamDedupe remembers
+const maxStreamDedupeEntries = 10000
+
+// WithStreamDedupe makes StreamTodos and StreamTodosOrdered skip IDs that any
+// stream of this client emitted a successful result for within the last
+// window, so consumers fed overlapping ID sets over time see each todo once
+// per window. Failed results aren't remembered, so failures can be retried.
+// At most 10000 IDs are remembered; beyond that the oldest are forgotten early.
+func WithStreamDedupe(window time.Duration) Option {
+	return func(c *Client) {
+		c.streamDedupe = newStreamDeduper(window, maxStreamDedupeEntries)
+	}
+}
+
+// streamDeduper remembers when IDs were last emitted, oldest first
+type streamDeduper struct {
+	window time.Duration
+	limit  int
+
+	mu      sync.Mutex
+	emitted map[int]*list.Element
+	order   list.List
+}
+
+// emission is an entry of streamDeduper.order
+type emission struct {
+	id int
+	at time.Time
+}
+
+func newStreamDeduper(window time.Duration, limit int) *streamDeduper {
+	return &streamDeduper{window: window, limit: limit, emitted: make(map[int]*list.Element)}
+}
+
+// recent reports whether id was emitted within the window
+func (d *streamDeduper) recent(id int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire(time.Now())
+	_, ok := d.emitted[id]
+	return ok
+}
+
+// claim records id as emitted now, reporting false if it already was within
+// the window. The returned entry lets unclaim take it back.
+func (d *streamDeduper) claim(id int) (*list.Element, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	d.expire(now)
+	if _, ok := d.emitted[id]; ok {
+		return nil, false
+	}
+	if d.order.Len() >= d.limit {
+		oldest := d.order.Front()
+		delete(d.emitted, oldest.Value.(emission).id)
+		d.order.Remove(oldest)
+	}
+	e := d.order.PushBack(emission{id: id, at: now})
+	d.emitted[id] = e
+	return e, true
+}
+
+// unclaim forgets a claim whose result was never delivered, unless it has
+// already expired or been evicted
+func (d *streamDeduper) unclaim(e *list.Element) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := e.Value.(emission).id
+	if d.emitted[id] == e {
+		delete(d.emitted, id)
+		d.order.Remove(e)
+	}
+}
+
+// expire forgets emissions older than the window. Callers hold d.mu.
+func (d *streamDeduper) expire(now time.Time) {
+	for e := d.order.Front(); e != nil; e = d.order.Front() {
+		em := e.Value.(emission)
+		if now.Sub(em.at) < d.window {
+			return
+		}
+		delete(d.emitted, em.id)
+		d.order.Remove(e)
+	}
+}
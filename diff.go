@@ -0,0 +1,28 @@
+package main
+
+// FieldChange describes one field that differs between two todos
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// DiffTodos reports the fields that changed from a to b, in struct order.
+// Differing IDs are reported as an "ID" change so callers can tell they are
+// comparing different records.
+func DiffTodos(a, b Todo) []FieldChange {
+	var changes []FieldChange
+	if a.UserID != b.UserID {
+		changes = append(changes, FieldChange{Field: "UserID", Old: a.UserID, New: b.UserID})
+	}
+	if a.ID != b.ID {
+		changes = append(changes, FieldChange{Field: "ID", Old: a.ID, New: b.ID})
+	}
+	if a.Title != b.Title {
+		changes = append(changes, FieldChange{Field: "Title", Old: a.Title, New: b.Title})
+	}
+	if a.Completed != b.Completed {
+		changes = append(changes, FieldChange{Field: "Completed", Old: a.Completed, New: b.Completed})
+	}
+	return changes
+}
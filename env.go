@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// WithDefaultTimeoutFromEnv lets the environment variable name override the
+// default timeout, so ops can tune it without a code change. Its value is a Go
+// duration such as "5s" or "1m30s", and "0" disables the default timeout. It
+// takes precedence over WithTimeout whatever the option order. When name is
+// unset or empty, or its value is malformed or negative, the timeout from
+// WithTimeout, or the built-in default, is kept and NewClient logs a warning
+// through the standard log package.
+func WithDefaultTimeoutFromEnv(name string) Option {
+	return func(c *Client) {
+		c.timeoutEnv = name
+	}
+}
+
+// applyTimeoutEnv reads the variable set by WithDefaultTimeoutFromEnv, once
+// every option has been applied so the fallback timeout is known
+func (c *Client) applyTimeoutEnv() {
+	if c.timeoutEnv == "" {
+		return
+	}
+	value := strings.TrimSpace(os.Getenv(c.timeoutEnv))
+	if value == "" {
+		log.Printf("warning: %s is unset, keeping the default timeout %v", c.timeoutEnv, c.timeout)
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("warning: ignoring malformed timeout %q in %s, keeping the default timeout %v", value, c.timeoutEnv, c.timeout)
+		return
+	}
+	c.timeout = d
+}
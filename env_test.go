@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultTimeoutFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		warning string
+	}{
+		{"valid duration", "1m30s", 90 * time.Second, ""},
+		{"zero disables", "0", 0, ""},
+		{"unset", "", 7 * time.Second, "TODO_CLIENT_TIMEOUT is unset"},
+		{"malformed", "soon", 7 * time.Second, `malformed timeout "soon"`},
+		{"negative", "-5s", 7 * time.Second, `malformed timeout "-5s"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TODO_CLIENT_TIMEOUT", tt.value)
+			var logged bytes.Buffer
+			out := log.Writer()
+			log.SetOutput(&logged)
+			defer log.SetOutput(out)
+
+			c, err := NewClient(WithTimeout(7*time.Second), WithDefaultTimeoutFromEnv("TODO_CLIENT_TIMEOUT"))
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+			defer c.Close()
+			if c.timeout != tt.want {
+				t.Errorf("timeout = %v, want %v", c.timeout, tt.want)
+			}
+			switch {
+			case tt.warning == "" && logged.Len() > 0:
+				t.Errorf("unexpected warning %q", logged.String())
+			case !strings.Contains(logged.String(), tt.warning):
+				t.Errorf("logged %q, want a warning containing %q", logged.String(), tt.warning)
+			}
+		})
+	}
+}
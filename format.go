@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteTodosCSV writes todos to w as CSV with a header row
+func WriteTodosCSV(w io.Writer, todos []Todo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"UserID", "ID", "Title", "Completed"}); err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		record := []string{
+			strconv.Itoa(todo.UserID),
+			strconv.Itoa(todo.ID),
+			todo.Title,
+			strconv.FormatBool(todo.Completed),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// titleWidth is how much of a title FormatTodosTable shows before truncating
+const titleWidth = 30
+
+// FormatTodosTable renders todos as aligned ID/Status/Title columns
+func FormatTodosTable(todos []Todo) string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tStatus\tTitle")
+	for _, todo := range todos {
+		status := "Pending"
+		if todo.Completed {
+			status = "Completed"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", todo.ID, status, truncateString(todo.Title, titleWidth))
+	}
+	tw.Flush()
+	return b.String()
+}
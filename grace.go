@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// WithDeadlineGrace ends the network part of each call d before the context's
+// deadline, leaving that long for local work on the response, such as
+// transforms, hooks and the caller's own handling, before the deadline hits.
+// This trades a little network budget for reliable completion: a response that
+// would have arrived in the last d now fails with a deadline error instead,
+// while one that arrives in time is never cut off part way through handling.
+// The response body must be received within the shortened deadline too. At
+// most half the remaining time is reserved, so calls with little time left
+// still get to try, and calls without a deadline are unaffected.
+func WithDeadlineGrace(d time.Duration) Option {
+	return func(c *Client) {
+		c.deadlineGrace = d
+	}
+}
+
+// withDeadlineGrace shortens ctx's deadline by the grace set by WithDeadlineGrace,
+// with cause as the shortened deadline's cause, or context.DeadlineExceeded
+// when nil
+func (c *Client) withDeadlineGrace(ctx context.Context, cause error) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if c.deadlineGrace <= 0 || !ok {
+		return ctx, func() {}
+	}
+	grace := min(c.deadlineGrace, time.Until(deadline)/2)
+	if grace <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithDeadlineCause(ctx, deadline.Add(-grace), cause)
+}
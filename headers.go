@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contextHeader maps a context value onto an outgoing request header
+type contextHeader struct {
+	header string
+	key    any
+}
+
+// WithContextHeader sends the value stored in the request context under key as
+// the named header, formatted with fmt.Sprint. Requests whose context has no
+// value for key don't get the header.
+func WithContextHeader(header string, key any) Option {
+	return func(c *Client) {
+		c.contextHeaders = append(c.contextHeaders, contextHeader{header: http.CanonicalHeaderKey(header), key: key})
+	}
+}
+
+// WithHeaderKeyAllowlist restricts WithContextHeader mappings to the listed
+// header names, so context values can't leak into other headers by mistake.
+// Mappings to unlisted headers are dropped and a warning is logged.
+func WithHeaderKeyAllowlist(headers []string) Option {
+	return func(c *Client) {
+		c.headerAllowlist = make(map[string]bool, len(headers))
+		for _, h := range headers {
+			c.headerAllowlist[http.CanonicalHeaderKey(h)] = true
+		}
+	}
+}
+
+// applyContextHeaders sets the headers mapped from ctx on req
+func (c *Client) applyContextHeaders(ctx context.Context, req *http.Request, logger Logger) {
+	for _, m := range c.contextHeaders {
+		v := ctx.Value(m.key)
+		if v == nil {
+			continue
+		}
+		if c.headerAllowlist != nil && !c.headerAllowlist[m.header] {
+			logger.Log("dropping context header not in allowlist", "header", m.header)
+			continue
+		}
+		req.Header.Set(m.header, fmt.Sprint(v))
+	}
+}
+
+// requestTimeoutHeader carries the caller's remaining time budget in milliseconds
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// WithResponseDeadlineHeader sends the time left before the request context's
+// deadline, in milliseconds, as the X-Request-Timeout header so the server can
+// abandon work the client has already given up on. It is recomputed for each
+// attempt and left out when the context has no deadline.
+func WithResponseDeadlineHeader() Option {
+	return func(c *Client) {
+		c.deadlineHeader = true
+	}
+}
+
+// applyDeadlineHeader sets X-Request-Timeout on req from ctx's deadline
+func applyDeadlineHeader(ctx context.Context, req *http.Request) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+		req.Header.Set(requestTimeoutHeader, strconv.FormatInt(remaining, 10))
+	}
+}
+
+// languageKey is the context key for ContextWithLanguage
+type languageKey struct{}
+
+// WithLanguage sends tags as the Accept-Language header of every request,
+// e.g. "fr" or "en;q=0.9, fr;q=0.8" to list several preferences with quality
+// values. ContextWithLanguage overrides it per request.
+func WithLanguage(tags string) Option {
+	return func(c *Client) {
+		c.language = strings.TrimSpace(tags)
+	}
+}
+
+// ContextWithLanguage returns a copy of ctx whose requests send tags as their
+// Accept-Language header, in place of any WithLanguage default. The value is
+// sent as given, so it takes the same form WithLanguage does.
+func ContextWithLanguage(ctx context.Context, tags string) context.Context {
+	return context.WithValue(ctx, languageKey{}, strings.TrimSpace(tags))
+}
+
+// applyLanguage sets Accept-Language on req from ctx or the client default
+func (c *Client) applyLanguage(ctx context.Context, req *http.Request) {
+	tags := c.language
+	if v, ok := ctx.Value(languageKey{}).(string); ok && v != "" {
+		tags = v
+	}
+	if tags != "" {
+		req.Header.Set("Accept-Language", tags)
+	}
+}
+
+// checkLanguage validates an Accept-Language value: comma-separated language
+// tags, each optionally weighted with a q value between 0 and 1
+func checkLanguage(tags string) error {
+	for _, part := range strings.Split(tags, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || strings.ContainsAny(tag, " \t") {
+			return fmt.Errorf("invalid language tag %q", part)
+		}
+		if params == "" {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return fmt.Errorf("invalid parameter %q for language %q", params, tag)
+		}
+		if weight, err := strconv.ParseFloat(q, 64); err != nil || weight < 0 || weight > 1 {
+			return fmt.Errorf("invalid quality value %q for language %q", q, tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// minHedgeSamples is how many recent latencies adaptive hedging needs before
+// it starts hedging
+const minHedgeSamples = 20
+
+// hedgePercentile is the share of recent calls adaptive hedging waits for
+const hedgePercentile = 0.95
+
+// maxHedgeDrain is how much of a losing response's body is read so its
+// connection can be reused; larger remainders just close the connection
+const maxHedgeDrain = 4 << 10
+
+// WithHedging cuts tail latency on GET and HEAD requests: when an attempt has
+// had no response after delay, an identical request is sent alongside it, up
+// to maxHedges extra per attempt, and whichever responds first is used. The
+// others are cancelled and their bodies drained. A delay of 0 or less waits
+// for the 95th percentile of recent call latencies instead, and doesn't hedge
+// until 20 calls have completed. Other methods are never hedged, since
+// sending them twice may not be safe. A call made with WithCallRetry sends at
+// most its policy's MaxAttempts-1 hedges.
+func WithHedging(delay time.Duration, maxHedges int) Option {
+	return func(c *Client) {
+		if maxHedges < 1 {
+			return
+		}
+		c.transports = append(c.transports, func(next http.RoundTripper) http.RoundTripper {
+			return &hedgingTransport{next: next, delay: delay, maxHedges: maxHedges, latencies: c.latencies}
+		})
+	}
+}
+
+// hedgingTransport races duplicate read requests against a slow first one
+type hedgingTransport struct {
+	next      http.RoundTripper
+	delay     time.Duration
+	maxHedges int
+	latencies *LatencyTracker
+}
+
+// hedgeDelay returns how long to wait before each hedge, or false to not hedge
+func (t *hedgingTransport) hedgeDelay() (time.Duration, bool) {
+	if t.delay > 0 {
+		return t.delay, true
+	}
+	recent := t.latencies.Recent()
+	if len(recent) < minHedgeSamples {
+		return 0, false
+	}
+	slices.Sort(recent)
+	return recent[int(float64(len(recent)-1)*hedgePercentile)], true
+}
+
+// hedgeOutcome is the result of one of the raced requests
+type hedgeOutcome struct {
+	racer int
+	resp  *http.Response
+	err   error
+}
+
+func (t *hedgingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+	maxHedges := t.maxHedges
+	if p, ok := callRetry(req.Context()); ok {
+		maxHedges = min(maxHedges, p.MaxAttempts-1)
+	}
+	delay, ok := t.hedgeDelay()
+	if !ok || maxHedges < 1 {
+		return t.next.RoundTrip(req)
+	}
+
+	// Buffered so racers never block once the winner is chosen
+	outcomes := make(chan hedgeOutcome, maxHedges+1)
+	var cancels []context.CancelFunc
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		racer := len(cancels)
+		cancels = append(cancels, cancel)
+		clone := req.Clone(ctx)
+		go func() {
+			resp, err := t.next.RoundTrip(clone)
+			outcomes <- hedgeOutcome{racer: racer, resp: resp, err: err}
+		}()
+	}
+	launch()
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) <= maxHedges && req.Context().Err() == nil {
+				launch()
+				pending++
+				timer.Reset(delay)
+			}
+		case o := <-outcomes:
+			pending--
+			if o.err != nil && pending > 0 {
+				// Another racer may still succeed
+				cancels[o.racer]()
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != o.racer {
+					cancel()
+				}
+			}
+			go discardHedges(outcomes, pending)
+			if o.err != nil {
+				cancels[o.racer]()
+				return nil, o.err
+			}
+			// The winner's context must outlive its body
+			o.resp.Body = &cancelOnClose{ReadCloser: o.resp.Body, cancel: cancels[o.racer]}
+			return o.resp, nil
+		}
+	}
+}
+
+// discardHedges drains whatever the n cancelled racers still outstanding
+// return, so their connections can be reused
+func discardHedges(outcomes <-chan hedgeOutcome, n int) {
+	for range n {
+		o := <-outcomes
+		if o.resp != nil {
+			io.Copy(io.Discard, io.LimitReader(o.resp.Body, maxHedgeDrain))
+			o.resp.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose cancels a request's context once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many recent latencies the Client keeps
+const latencyWindow = 1000
+
+// LatencyTracker keeps the most recent request latencies in a fixed-size ring buffer
+type LatencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyTracker creates a tracker that remembers the last size latencies
+func NewLatencyTracker(size int) *LatencyTracker {
+	if size < 1 {
+		size = 1
+	}
+	return &LatencyTracker{samples: make([]time.Duration, size)}
+}
+
+// Record adds d, evicting the oldest latency once the buffer is full
+func (t *LatencyTracker) Record(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % len(t.samples)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Recent returns a copy of the recorded latencies, oldest first
+func (t *LatencyTracker) Recent() []time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.full {
+		return append([]time.Duration(nil), t.samples[:t.next]...)
+	}
+	recent := make([]time.Duration, 0, len(t.samples))
+	recent = append(recent, t.samples[t.next:]...)
+	return append(recent, t.samples[:t.next]...)
+}
+
+// RecentLatencies returns the durations of the client's last 1000 requests,
+// oldest first, including failed ones
+func (c *Client) RecentLatencies() []time.Duration {
+	return c.latencies.Recent()
+}
+
+// WithSlowThreshold calls onSlow with the URL and duration of every call that
+// takes longer than d, retries and backoff included, so latency regressions
+// can be alerted on. The call itself completes as usual: onSlow runs on its
+// own goroutine and never delays or fails it.
+func WithSlowThreshold(d time.Duration, onSlow func(url string, took time.Duration)) Option {
+	return func(c *Client) {
+		c.slowThreshold = d
+		c.onSlow = onSlow
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrClientClosed is returned by requests made after Close, and is the
+// cancellation cause of requests still running when Close gives up on them
+var ErrClientClosed = errors.New("client closed")
+
+// WithShutdownGrace makes Close wait up to d for in-flight requests to finish
+// before cancelling them
+func WithShutdownGrace(d time.Duration) Option {
+	return func(c *Client) {
+		c.shutdownGrace = d
+	}
+}
+
+// begin registers a request starting with ctx. It returns the context the
+// request should use, which also ends when the client is closed, and a
+// function to call once the request is done.
+func (c *Client) begin(ctx context.Context) (context.Context, func(), error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, nil, ErrClientClosed
+	}
+	c.active.Add(1)
+	base := c.baseCtx
+	c.mu.Unlock()
+	c.inFlight.Add(1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(base, func() {
+		cancel(context.Cause(base))
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+		c.inFlight.Add(-1)
+		c.active.Done()
+	}, nil
+}
+
+// InFlight returns how many calls are running right now. Each call counts
+// once however many attempts it makes, from the moment it starts until it
+// returns, successfully or not.
+func (c *Client) InFlight() int {
+	return int(c.inFlight.Load())
+}
+
+// Close stops the client accepting requests. With WithShutdownGrace, in-flight
+// requests get that long to finish; any still running afterwards are
+// cancelled with ErrClientClosed. Close returns once they have all stopped
+// and idle connections are closed.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	baseCancel := c.baseCancel
+	c.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.active.Wait()
+		close(drained)
+	}()
+
+	if c.shutdownGrace > 0 {
+		timer := time.NewTimer(c.shutdownGrace)
+		defer timer.Stop()
+		select {
+		case <-drained:
+		case <-timer.C:
+		}
+	}
+	baseCancel(ErrClientClosed)
+	<-drained
+	if c.tapStop != nil {
+		close(c.tapStop)
+	}
+
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// ErrCancelledAll is the cancellation cause of requests aborted by CancelAll
+var ErrCancelledAll = errors.New("requests cancelled by CancelAll")
+
+// CancelAll immediately cancels every request in flight, with ErrCancelledAll
+// as the cause, without closing the client. Requests started after CancelAll
+// returns run normally, since it replaces the cancelled parent context with a
+// fresh one; a request racing with the call may land on either side. Unlike
+// Close it doesn't wait for the cancelled requests to return.
+func (c *Client) CancelAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.baseCancel(ErrCancelledAll)
+	c.baseCtx, c.baseCancel = context.WithCancelCause(context.Background())
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInFlightCountsRetryingCallsOnce(t *testing.T) {
+	const maxConc = 3
+	release := make(chan struct{})
+	var (
+		mu      sync.Mutex
+		retried = map[string]bool{}
+		waiting atomic.Int32
+		peak    atomic.Int64
+		c       *Client
+	)
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if n := int64(c.InFlight()); n > peak.Load() {
+			peak.Store(n)
+		}
+		mu.Lock()
+		first := !retried[r.URL.Path]
+		retried[r.URL.Path] = true
+		mu.Unlock()
+		// Every call fails once, then holds its retry until released
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		waiting.Add(1)
+		<-release
+		writeJSON(w, `{"id":1}`)
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	done := make(chan map[int]error)
+	go func() {
+		_, errs := FetchResources[Todo](context.Background(), c, "todos", ids, maxConc)
+		done <- errs
+	}()
+
+	waitFor(t, "the first calls to reach their retry", func() bool { return waiting.Load() == maxConc })
+	if got := c.InFlight(); got != maxConc {
+		t.Errorf("InFlight() = %d with %d retrying calls, want %d", got, maxConc, maxConc)
+	}
+	close(release)
+	if errs := <-done; len(errs) > 0 {
+		t.Fatalf("batch failed: %v", errs)
+	}
+	if got := peak.Load(); got > maxConc {
+		t.Errorf("InFlight() peaked at %d, above the pool size %d", got, maxConc)
+	}
+	if got := c.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d after the batch, want 0", got)
+	}
+}
+
+func TestInFlightReturnsToZeroAfterErrors(t *testing.T) {
+	c, _ := statusServer(t, http.StatusInternalServerError, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	var wg sync.WaitGroup
+	for id := 1; id <= 20; id++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.FetchTodo(context.Background(), id); err == nil {
+				t.Errorf("FetchTodo(%d) succeeded against a failing server", id)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := c.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d after every call failed, want 0", got)
+	}
+}
+
+func TestCancelAllStopsBatchPromptly(t *testing.T) {
+	const maxConc = 4
+	var (
+		cancelled atomic.Bool
+		hung      atomic.Int32
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !cancelled.Load() {
+			// Requests sent before CancelAll hang until they're cancelled
+			hung.Add(1)
+			<-r.Context().Done()
+			return
+		}
+		writeJSON(w, `{"id":1}`)
+	}, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	ids := make([]int, 12)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	done := make(chan map[int]error)
+	go func() {
+		_, errs := FetchResources[Todo](context.Background(), c, "todos", ids, maxConc)
+		done <- errs
+	}()
+	waitFor(t, "the batch to fill its pool", func() bool { return hung.Load() == maxConc })
+
+	cancelled.Store(true)
+	start := time.Now()
+	c.CancelAll()
+	var errs map[int]error
+	select {
+	case errs = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch still running a second after CancelAll")
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("batch took %v to finish after CancelAll", took)
+	}
+
+	if len(errs) != maxConc {
+		t.Errorf("%d calls failed, want the %d in flight at CancelAll: %v", len(errs), maxConc, errs)
+	}
+	for id, err := range errs {
+		if !errors.Is(err, ErrCancelledAll) {
+			t.Errorf("todo %d failed with %v, want ErrCancelledAll", id, err)
+		}
+	}
+	if got := c.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d after CancelAll, want 0", got)
+	}
+	if _, err := c.FetchTodo(context.Background(), 1); err != nil {
+		t.Errorf("FetchTodo after CancelAll: %v", err)
+	}
+}
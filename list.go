@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// decodeArray streams a JSON array from dec into items, appending each element
+// as it's decoded so that elements read before a failure are kept. items is
+// emptied first, so a retried attempt doesn't repeat a failed one's elements.
+func decodeArray[T any](dec *json.Decoder, items *[]T) error {
+	*items = (*items)[:0]
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("error decoding response: expected array, got %v", tok)
+	}
+
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return fmt.Errorf("error decoding element %d: %w", len(*items), err)
+		}
+		*items = append(*items, item)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// FetchAllTodos fetches the full /todos list. The array is decoded as it streams,
+// so if the transfer fails part way the todos parsed so far are returned along
+// with the error.
+func (c *Client) FetchAllTodos(ctx context.Context) ([]Todo, error) {
+	return c.fetchAllTodos(ctx, nil, "FetchAllTodos")
+}
+
+// fetchAllTodos is FetchAllTodos for the Client method caller, describing the
+// response in meta when non-nil
+func (c *Client) fetchAllTodos(ctx context.Context, meta *ResponseMeta, caller string) ([]Todo, error) {
+	var todos []Todo
+	err := c.do(ctx, apiRequest{
+		method:   http.MethodGet,
+		resource: "todos",
+		path:     "/todos",
+		decode: func(body io.Reader) error {
+			return decodeArray(c.newDecoder(body), &todos)
+		},
+		meta:   meta,
+		caller: caller,
+	})
+	if err := transformEach(ctx, c, todos); err != nil {
+		return nil, err
+	}
+	return todos, err
+}
+
+// maxPages bounds FetchAllTodosPaged in case a server never returns a short page
+const maxPages = 1000
+
+// FetchTodosPage fetches one page of todos using 1-based page numbers. The total
+// is read from the X-Total-Count header and is -1 when the server doesn't send it.
+func (c *Client) FetchTodosPage(ctx context.Context, page, pageSize int) ([]Todo, int, error) {
+	return c.fetchTodosPage(ctx, page, pageSize, nil, "FetchTodosPage")
+}
+
+// fetchTodosPage is FetchTodosPage for the Client method caller, describing
+// the response in meta when non-nil
+func (c *Client) fetchTodosPage(ctx context.Context, page, pageSize int, meta *ResponseMeta, caller string) ([]Todo, int, error) {
+	if page < 1 || pageSize < 1 {
+		return nil, 0, fmt.Errorf("invalid page %d with size %d", page, pageSize)
+	}
+	var todos []Todo
+	total := -1
+	err := c.do(ctx, apiRequest{
+		method:   http.MethodGet,
+		resource: "todos",
+		path:     fmt.Sprintf("/todos?_page=%d&_limit=%d", page, pageSize),
+		decode: func(body io.Reader) error {
+			return decodeArray(c.newDecoder(body), &todos)
+		},
+		onHeader: func(h http.Header) {
+			if n, err := strconv.Atoi(h.Get("X-Total-Count")); err == nil {
+				total = n
+			}
+		},
+		meta:   meta,
+		caller: caller,
+	})
+	if err := transformEach(ctx, c, todos); err != nil {
+		return nil, total, err
+	}
+	return todos, total, err
+}
+
+// FetchTodosSince fetches up to limit todos with IDs above afterID, in ID
+// order, for resumable incremental syncs. It returns them with the cursor to
+// pass next time: the highest ID seen, or afterID again when nothing new came
+// back. Start from 0 to sync from the beginning.
+//
+// The request is /todos?_sort=id&_start=afterID&_limit=limit. _start is an
+// offset into the sorted list, so it only lines up with the cursor while IDs
+// are dense from 1, as they are on JSONPlaceholder. Todos at or below afterID
+// are dropped in case the server's offset lands earlier, but a server with
+// gaps in its IDs can make a sync skip todos.
+func (c *Client) FetchTodosSince(ctx context.Context, afterID, limit int) ([]Todo, int, error) {
+	return c.fetchTodosSince(ctx, afterID, limit, nil, "FetchTodosSince")
+}
+
+// fetchTodosSince is FetchTodosSince for the Client method caller, describing
+// the response in meta when non-nil
+func (c *Client) fetchTodosSince(ctx context.Context, afterID, limit int, meta *ResponseMeta, caller string) ([]Todo, int, error) {
+	if afterID < 0 || limit < 1 {
+		return nil, afterID, fmt.Errorf("invalid cursor %d with limit %d", afterID, limit)
+	}
+	var todos []Todo
+	err := c.do(ctx, apiRequest{
+		method:   http.MethodGet,
+		resource: "todos",
+		path:     fmt.Sprintf("/todos?_sort=id&_start=%d&_limit=%d", afterID, limit),
+		decode: func(body io.Reader) error {
+			return decodeArray(c.newDecoder(body), &todos)
+		},
+		meta:   meta,
+		caller: caller,
+	})
+
+	fresh := todos[:0]
+	next := afterID
+	for _, todo := range todos {
+		if todo.ID > afterID {
+			fresh = append(fresh, todo)
+			next = max(next, todo.ID)
+		}
+	}
+	if err := transformEach(ctx, c, fresh); err != nil {
+		return nil, afterID, err
+	}
+	return fresh, next, err
+}
+
+// FetchAllTodosPaged fetches every todo one page at a time, stopping at the first
+// short page or once the reported total is reached. On failure or cancellation
+// the todos fetched so far are returned with the error.
+func (c *Client) FetchAllTodosPaged(ctx context.Context, pageSize int) ([]Todo, error) {
+	var all []Todo
+	for page := 1; page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return all, fmt.Errorf("paged fetch stopped before page %d: %w", page, err)
+		}
+
+		todos, total, err := c.fetchTodosPage(ctx, page, pageSize, nil, "FetchAllTodosPaged")
+		all = append(all, todos...)
+		if err != nil {
+			return all, fmt.Errorf("page %d: %w", page, err)
+		}
+		if len(todos) < pageSize || (total >= 0 && len(all) >= total) {
+			return all, nil
+		}
+	}
+	return all, fmt.Errorf("paged fetch exceeded %d pages", maxPages)
+}
+
+// TodoFilter narrows a todo listing on the server. Zero-valued fields aren't filtered on.
+type TodoFilter struct {
+	UserID    int
+	Completed *bool
+}
+
+// query encodes f as list query parameters
+func (f TodoFilter) query() url.Values {
+	q := url.Values{}
+	if f.UserID != 0 {
+		q.Set("userId", strconv.Itoa(f.UserID))
+	}
+	if f.Completed != nil {
+		q.Set("completed", strconv.FormatBool(*f.Completed))
+	}
+	return q
+}
+
+// FetchTodosFiltered fetches the todos matching f, filtered server-side
+func (c *Client) FetchTodosFiltered(ctx context.Context, f TodoFilter) ([]Todo, error) {
+	return c.fetchTodosFiltered(ctx, f, nil, "FetchTodosFiltered")
+}
+
+// fetchTodosFiltered is FetchTodosFiltered for the Client method caller,
+// describing the response in meta when non-nil
+func (c *Client) fetchTodosFiltered(ctx context.Context, f TodoFilter, meta *ResponseMeta, caller string) ([]Todo, error) {
+	path := "/todos"
+	if q := f.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var todos []Todo
+	err := c.do(ctx, apiRequest{
+		method:   http.MethodGet,
+		resource: "todos",
+		path:     path,
+		decode: func(body io.Reader) error {
+			return decodeArray(c.newDecoder(body), &todos)
+		},
+		meta:   meta,
+		caller: caller,
+	})
+	if err := transformEach(ctx, c, todos); err != nil {
+		return nil, err
+	}
+	return todos, err
+}
+
+// FetchTodosForUser fetches all todos belonging to userID. Filtering happens on
+// the server via ?userId=N, so only that user's todos are transferred.
+func (c *Client) FetchTodosForUser(ctx context.Context, userID int) ([]Todo, error) {
+	return c.fetchTodosFiltered(ctx, TodoFilter{UserID: userID}, nil, "FetchTodosForUser")
+}
+
+// FetchCompletedTodos fetches userID's completed todos, or every user's when userID is 0
+func (c *Client) FetchCompletedTodos(ctx context.Context, userID int) ([]Todo, error) {
+	completed := true
+	return c.fetchTodosFiltered(ctx, TodoFilter{UserID: userID, Completed: &completed}, nil, "FetchCompletedTodos")
+}
+
+// FetchPendingTodos fetches userID's incomplete todos, or every user's when userID is 0
+func (c *Client) FetchPendingTodos(ctx context.Context, userID int) ([]Todo, error) {
+	completed := false
+	return c.fetchTodosFiltered(ctx, TodoFilter{UserID: userID, Completed: &completed}, nil, "FetchPendingTodos")
+}
+
+// FetchTodosForUsers fetches each user's todos concurrently and merges them
+// sorted by ID. If some users fail, the todos of the others are returned with
+// an error joining each failed user's error. Like FetchResources, it honors
+// WithFailFast, WithAdaptiveConcurrency and WithStartupJitter.
+func (c *Client) FetchTodosForUsers(ctx context.Context, userIDs []int) ([]Todo, error) {
+	lists, errs := fetchConcurrently(ctx, userIDs, c.batchOptions(defaultBatchConcurrency), func(ctx context.Context, userID int) (*[]Todo, error) {
+		todos, err := c.fetchTodosFiltered(ctx, TodoFilter{UserID: userID}, nil, "FetchTodosForUsers")
+		if err != nil {
+			return nil, err
+		}
+		return &todos, nil
+	})
+
+	var merged []Todo
+	for _, todos := range lists {
+		merged = append(merged, *todos...)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].ID < merged[j].ID })
+
+	if len(errs) == 0 {
+		return merged, nil
+	}
+	failed := make([]error, 0, len(errs))
+	for _, userID := range dedupeIDs(userIDs) {
+		if err, ok := errs[userID]; ok {
+			failed = append(failed, fmt.Errorf("user %d: %w", userID, err))
+		}
+	}
+	return merged, fmt.Errorf("%d of %d users failed: %w", len(errs), len(lists)+len(errs), errors.Join(failed...))
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// queryRecorder returns a handler answering every request with body, and the
+// function returning the query of the latest request
+func queryRecorder(body string) (http.HandlerFunc, func() url.Values) {
+	var latest atomic.Pointer[url.Values]
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		latest.Store(&q)
+		writeJSON(w, body)
+	}
+	return handler, func() url.Values {
+		if q := latest.Load(); q != nil {
+			return *q
+		}
+		return nil
+	}
+}
+
+func TestFetchAllTodosRetryDoesNotDuplicate(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body := `[{"id":1},{"id":2}]`
+		if calls.Add(1) == 1 {
+			// Promise more than is sent so the first transfer fails part way
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)+10))
+			writeJSON(w, body[:len(body)-1]+",")
+			return
+		}
+		writeJSON(w, body)
+	},
+		WithRetry(RetryPolicy{MaxAttempts: 2}),
+		WithErrorClassifier(func(*http.Response, error) bool { return true }),
+	)
+
+	todos, err := c.FetchAllTodos(context.Background())
+	if err != nil {
+		t.Fatalf("FetchAllTodos: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("got %d attempts, want 2", calls.Load())
+	}
+	if len(todos) != 2 || todos[0].ID != 1 || todos[1].ID != 2 {
+		t.Errorf("got %+v, want todos 1 and 2 once each", todos)
+	}
+}
+
+func TestFetchTodosForUserFiltersOnServer(t *testing.T) {
+	handler, query := queryRecorder(`[{"userId":7,"id":1}]`)
+	c := newTestClient(t, handler)
+
+	todos, err := c.FetchTodosForUser(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("FetchTodosForUser: %v", err)
+	}
+	if got := query().Encode(); got != "userId=7" {
+		t.Errorf("query is %q, want userId=7", got)
+	}
+	if len(todos) != 1 || todos[0].UserID != 7 {
+		t.Errorf("got %+v", todos)
+	}
+}
+
+func TestCompletedAndPendingFilters(t *testing.T) {
+	handler, query := queryRecorder(`[]`)
+	c := newTestClient(t, handler)
+
+	tests := []struct {
+		name  string
+		fetch func(context.Context, int) ([]Todo, error)
+		user  int
+		want  string
+	}{
+		{"completed for user", c.FetchCompletedTodos, 3, "completed=true&userId=3"},
+		{"completed for all", c.FetchCompletedTodos, 0, "completed=true"},
+		{"pending for user", c.FetchPendingTodos, 3, "completed=false&userId=3"},
+		{"pending for all", c.FetchPendingTodos, 0, "completed=false"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.fetch(context.Background(), tt.user); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := query().Encode(); got != tt.want {
+			t.Errorf("%s: query is %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFetchTodosForUsersPartialFailure(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("userId") {
+		case "1":
+			writeJSON(w, `[{"userId":1,"id":3},{"userId":1,"id":1}]`)
+		case "2":
+			writeJSON(w, `[{"userId":2,"id":2}]`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	todos, err := c.FetchTodosForUsers(context.Background(), []int{1, 2, 9})
+	if err == nil {
+		t.Fatal("want an error for the failed user")
+	}
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("error %v doesn't wrap the failed user's 500", err)
+	}
+	if !strings.Contains(err.Error(), "user 9") || !strings.Contains(err.Error(), "1 of 3 users failed") {
+		t.Errorf("error %q doesn't name the failed user", err)
+	}
+	var ids []int
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Errorf("got todo IDs %v, want the other users' todos merged as [1 2 3]", ids)
+	}
+}
+
+func TestFetchTodosForUsersFailFast(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, WithFailFast(), WithBulkhead("todos", 1))
+
+	userIDs := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	if _, err := c.FetchTodosForUsers(context.Background(), userIDs); err == nil {
+		t.Fatal("want an error")
+	}
+	if got := calls.Load(); got >= int32(len(userIDs)) {
+		t.Errorf("server got %d requests, want WithFailFast to stop the batch early", got)
+	}
+}
+
+// cursorServer serves todos like json-server, honoring _sort=id, _start and
+// _limit on /todos. The store is kept out of ID order so _sort matters.
+func cursorServer(t *testing.T, ids ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		todos := slices.Clone(ids)
+		if q.Get("_sort") == "id" {
+			slices.Sort(todos)
+		}
+		start, err := strconv.Atoi(q.Get("_start"))
+		if err != nil {
+			t.Errorf("bad _start in %s", r.URL)
+		}
+		limit, err := strconv.Atoi(q.Get("_limit"))
+		if err != nil {
+			t.Errorf("bad _limit in %s", r.URL)
+		}
+		todos = todos[min(start, len(todos)):min(start+limit, len(todos))]
+
+		items := make([]string, len(todos))
+		for i, id := range todos {
+			items[i] = `{"id":` + strconv.Itoa(id) + `}`
+		}
+		writeJSON(w, "["+strings.Join(items, ",")+"]")
+	}
+}
+
+func TestFetchTodosSinceResumesFromCursor(t *testing.T) {
+	c := newTestClient(t, cursorServer(t, 5, 2, 7, 1, 4, 3, 6))
+
+	var synced []int
+	cursor := 0
+	for _, want := range [][]int{{1, 2, 3}, {4, 5, 6}, {7}, {}} {
+		todos, next, err := c.FetchTodosSince(context.Background(), cursor, 3)
+		if err != nil {
+			t.Fatalf("FetchTodosSince(%d): %v", cursor, err)
+		}
+		var got []int
+		for _, todo := range todos {
+			got = append(got, todo.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("FetchTodosSince(%d) = %v, want %v", cursor, got, want)
+		}
+		wantNext := cursor
+		if len(want) > 0 {
+			wantNext = want[len(want)-1]
+		}
+		if next != wantNext {
+			t.Fatalf("FetchTodosSince(%d) cursor = %d, want %d", cursor, next, wantNext)
+		}
+		synced = append(synced, got...)
+		cursor = next
+	}
+	if !slices.Equal(synced, []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("synced %v", synced)
+	}
+}
+
+func TestFetchTodosSinceSendsCursor(t *testing.T) {
+	handler, query := queryRecorder(`[]`)
+	c := newTestClient(t, handler)
+
+	if _, _, err := c.FetchTodosSince(context.Background(), 40, 10); err != nil {
+		t.Fatalf("FetchTodosSince: %v", err)
+	}
+	q := query()
+	if q.Get("_sort") != "id" || q.Get("_start") != "40" || q.Get("_limit") != "10" {
+		t.Errorf("query = %v, want _sort=id&_start=40&_limit=10", q)
+	}
+}
+
+func TestFetchTodosSinceIgnoresSeenIDs(t *testing.T) {
+	// A server that returns what the cursor already covered mustn't move it back
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `[{"id":2},{"id":3},{"id":4}]`)
+	})
+
+	todos, next, err := c.FetchTodosSince(context.Background(), 3, 3)
+	if err != nil {
+		t.Fatalf("FetchTodosSince: %v", err)
+	}
+	if len(todos) != 1 || todos[0].ID != 4 || next != 4 {
+		t.Errorf("got %v with cursor %d, want only todo 4 with cursor 4", todos, next)
+	}
+}
+
+func TestFetchTodosSinceRejectsInvalidCursor(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	})
+
+	for _, tc := range []struct{ afterID, limit int }{{-1, 10}, {0, 0}} {
+		if _, next, err := c.FetchTodosSince(context.Background(), tc.afterID, tc.limit); err == nil || next != tc.afterID {
+			t.Errorf("FetchTodosSince(%d, %d) = cursor %d, %v; want an error keeping the cursor", tc.afterID, tc.limit, next, err)
+		}
+	}
+}
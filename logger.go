@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger receives the Client's log lines along with structured key/value fields
+type Logger interface {
+	// Log writes msg with the logger's fields followed by keyvals
+	Log(msg string, keyvals ...any)
+	// With returns a child logger that adds keyvals to every line
+	With(keyvals ...any) Logger
+}
+
+// WithLogger sets the logger used for per-request logging
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithLogSampling logs only the given fraction (0 to 1) of requests, chosen once
+// per request using the client's random source. Failed attempts are always logged.
+func WithLogSampling(rate float64) Option {
+	return func(c *Client) {
+		c.logSampleRate = rate
+	}
+}
+
+// sampleLog decides whether a new request's routine log lines are written
+func (c *Client) sampleLog() bool {
+	if c.logSampleRate >= 1 {
+		return true
+	}
+	return c.rand.Float64() < c.logSampleRate
+}
+
+// nopLogger discards everything; it is the Client's default
+type nopLogger struct{}
+
+func (nopLogger) Log(string, ...any) {}
+func (nopLogger) With(...any) Logger { return nopLogger{} }
+
+// stdLogger writes key=value lines to a standard library logger
+type stdLogger struct {
+	l      *log.Logger
+	fields []any
+}
+
+// NewStdLogger adapts a *log.Logger to the Logger interface.
+// A nil l writes through the standard logger.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &stdLogger{l: l}
+}
+
+func (s *stdLogger) Log(msg string, keyvals ...any) {
+	var b strings.Builder
+	b.WriteString(msg)
+	writeFields(&b, s.fields)
+	writeFields(&b, keyvals)
+	s.l.Print(b.String())
+}
+
+func (s *stdLogger) With(keyvals ...any) Logger {
+	fields := make([]any, 0, len(s.fields)+len(keyvals))
+	fields = append(fields, s.fields...)
+	fields = append(fields, keyvals...)
+	return &stdLogger{l: s.l, fields: fields}
+}
+
+// writeFields appends keyvals to b as " key=value" pairs
+func writeFields(b *strings.Builder, keyvals []any) {
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(b, " %v=%v", keyvals[i], keyvals[i+1])
+		} else {
+			fmt.Fprintf(b, " %v=(missing)", keyvals[i])
+		}
+	}
+}
@@ -3,12 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -36,8 +36,13 @@ func fetchTodoWithErrorChan(ctx context.Context, todoID int) (<-chan *Todo, <-ch
 		delay := 3 * time.Second
 		log.Printf("Starting request for todo %d (artificial delay: %v)...\n", todoID, delay)
 		
+		// Stop the timer on cancellation so repeated cancelled fetches don't
+		// leave it running for the rest of the delay
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
 		select {
-		case <-time.After(delay):
+		case <-timer.C:
 			// Continue after delay
 		case <-ctx.Done():
 			errChan <- fmt.Errorf("request cancelled before starting: %v", ctx.Err())
@@ -84,84 +89,141 @@ func fetchTodoWithErrorChan(ctx context.Context, todoID int) (<-chan *Todo, <-ch
 	return todoChan, errChan
 }
 
+// awaitTodo waits for the outcome of fetchTodoWithErrorChan or for ctx to end
+func awaitTodo(ctx context.Context, todoChan <-chan *Todo, errChan <-chan error) (*Todo, error) {
+	select {
+	case todo := <-todoChan:
+		if todo != nil {
+			return todo, nil
+		}
+		// todoChan was closed without a result, so the error is buffered
+		if err := <-errChan; err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("received nil todo")
+	case err := <-errChan:
+		if err != nil {
+			return nil, err
+		}
+		// errChan was closed without an error, so the todo is buffered
+		if todo := <-todoChan; todo != nil {
+			return todo, nil
+		}
+		return nil, fmt.Errorf("received nil todo")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TodoResult is the outcome of a single todo fetch along with its deadline timing
+type TodoResult struct {
+	Result[Todo]
+	// DeadlineSlack is how much time was left before the deadline when the
+	// fetch finished; it is zero or negative when the deadline was hit
+	DeadlineSlack time.Duration
+	// TimedOut is set when the fetch failed because the deadline passed
+	TimedOut bool
+}
+
 // simulateSlowRequest simulates a slow request that takes at least the specified duration
-func simulateSlowRequest(ctx context.Context, todoID int, minDuration time.Duration) (*Todo, error) {
+func simulateSlowRequest(ctx context.Context, todoID int, minDuration time.Duration) TodoResult {
 	// Create a new context with the minimum duration
 	timeoutCtx, cancel := context.WithTimeout(ctx, minDuration)
 	defer cancel()
+	deadline, _ := timeoutCtx.Deadline()
+	start := time.Now()
 
 	// Get the result and error channels
 	todoChan, errChan := fetchTodoWithErrorChan(timeoutCtx, todoID)
 
 	// Wait for either the result, error, or timeout
-	select {
-	case todo := <-todoChan:
-		if todo == nil {
-			return nil, fmt.Errorf("received nil todo")
-		}
-		
-		return todo, nil
-	case err := <-errChan:
-		return nil, err
-	case <-ctx.Done():
-		return nil, fmt.Errorf("request timed out after %v: %v", minDuration, ctx.Err())
+	todo, err := awaitTodo(timeoutCtx, todoChan, errChan)
+	result := TodoResult{
+		Result:        Result[Todo]{ID: todoID, Value: todo, Err: err, Latency: time.Since(start), Attempts: 1},
+		DeadlineSlack: time.Until(deadline),
 	}
+	if err != nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+		result.Err = fmt.Errorf("request timed out after %v: %v", minDuration, err)
+	}
+	return result
 }
 
-// fetchMultipleTodos demonstrates handling multiple concurrent requests
-func fetchMultipleTodos(ctx context.Context, ids ...int) ([]*Todo, error) {
-	var wg sync.WaitGroup
-	todos := make([]*Todo, 0, len(ids))
-	errs := make([]error, 0)
-	mu := sync.Mutex{}
-
-	for _, id := range ids {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			
-			todoChan, errChan := fetchTodoWithErrorChan(ctx, id)
-			
-			select {
-			case todo := <-todoChan:
-				if todo != nil {
-					mu.Lock()
-					todos = append(todos, todo)
-					mu.Unlock()
-				}
-			case err := <-errChan:
-				if err != nil {
-					mu.Lock()
-					errs = append(errs, fmt.Errorf("todo %d: %v", id, err))
-					mu.Unlock()
-				}
-			case <-ctx.Done():
-				// Context was cancelled, just return
-				return
-			}
-		}(id)
+// defaultPerRequestTimeout caps each individual fetch in fetchMultipleTodos so a
+// single stalled request can't occupy its goroutine for the whole batch deadline
+const defaultPerRequestTimeout = 4 * time.Second
+
+// multiFetchOptions controls how fetchMultipleTodos runs a batch
+type multiFetchOptions struct {
+	// perRequestTimeout caps each fetch; 0 or less means defaultPerRequestTimeout
+	perRequestTimeout time.Duration
+	// maxConc bounds the fetches in flight; 0 or less means unbounded
+	maxConc int
+	// failFast cancels the remaining fetches after the first failure, with a
+	// *FailFastError naming it as the cause
+	failFast bool
+}
+
+// ErrAllFailed is returned by fetchMultipleTodos when not a single todo was fetched
+var ErrAllFailed = errors.New("all requests failed")
+
+// fetchMultipleTodos demonstrates handling multiple concurrent requests.
+// When some fetches fail the todos that succeeded are returned along with
+// the joined errors; when every fetch fails the slice is nil and the error
+// wraps ErrAllFailed.
+func fetchMultipleTodos(ctx context.Context, opts multiFetchOptions, ids ...int) ([]*Todo, error) {
+	perRequestTimeout := opts.perRequestTimeout
+	if perRequestTimeout <= 0 {
+		perRequestTimeout = defaultPerRequestTimeout
 	}
+	todos, errs := fetchConcurrently(ctx, ids, batchOptions{maxConc: opts.maxConc, failFast: opts.failFast}, func(ctx context.Context, id int) (*Todo, error) {
+		reqCtx, cancel := context.WithTimeout(ctx, perRequestTimeout)
+		defer cancel()
 
-	// Wait for all goroutines to complete
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+		todoChan, errChan := fetchTodoWithErrorChan(reqCtx, id)
 
-	// Wait for either all goroutines to complete or context to be cancelled
-	select {
-	case <-done:
-		// All goroutines completed
-	case <-ctx.Done():
-		// Context was cancelled, return what we have so far
+		todo, err := awaitTodo(reqCtx, todoChan, errChan)
+		if err != nil && reqCtx.Err() != nil && ctx.Err() == nil {
+			// Only this request timed out; record it and free the worker
+			return nil, fmt.Errorf("request timed out after %v: %w", perRequestTimeout, reqCtx.Err())
+		}
+		return todo, err
+	})
+
+	if len(errs) == 0 {
+		return todos, nil
 	}
 
-	// Return any errors we encountered
-	if len(errs) > 0 {
-		return todos, fmt.Errorf("%d errors occurred: %v", len(errs), errs[0])
+	// Join the errors in input order, each ID once
+	failed := make([]error, 0, len(errs))
+	for _, id := range dedupeIDs(ids) {
+		if err, ok := errs[id]; ok {
+			failed = append(failed, fmt.Errorf("todo %d: %w", id, err))
+		}
 	}
-	return todos, nil
+	joined := errors.Join(failed...)
+	if len(todos) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrAllFailed, joined)
+	}
+	return todos, fmt.Errorf("%d of %d todos failed: %w", len(errs), len(todos)+len(errs), joined)
+}
+
+// FetchMultipleTodosTimeout is fetchMultipleTodos bounded by timeout, with the
+// default per-request timeout, for callers that don't want to build the
+// context themselves
+func FetchMultipleTodosTimeout(parent context.Context, timeout time.Duration, ids ...int) ([]*Todo, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	return fetchMultipleTodos(ctx, multiFetchOptions{}, ids...)
+}
+
+// FetchMultipleTodosFailFast is FetchMultipleTodosTimeout stopping at the first
+// failure. The fetches it stopped report a *FailFastError wrapping that failure,
+// which is also the cause of the context they saw.
+func FetchMultipleTodosFailFast(parent context.Context, timeout time.Duration, ids ...int) ([]*Todo, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	return fetchMultipleTodos(ctx, multiFetchOptions{failFast: true}, ids...)
 }
 
 // truncateString shortens a string to the specified length and adds "..." if truncated
@@ -217,23 +279,18 @@ func main() {
 		log.Printf("Fetching %d todos concurrently...\n", len(ids))
 		
 		start := time.Now()
-		todos, err := fetchMultipleTodos(ctx, ids...)
+		todos, err := fetchMultipleTodos(ctx, multiFetchOptions{perRequestTimeout: defaultPerRequestTimeout}, ids...)
 		elapsed := time.Since(start).Round(time.Millisecond)
 		
 		log.Printf("\nCompleted in %v", elapsed)
 		
 		// Print results
 		log.Printf("\nSuccessfully fetched %d/%d todos:", len(todos), len(ids))
+		fetched := make([]Todo, 0, len(todos))
 		for _, todo := range todos {
-			status := "Pending"
-			if todo.Completed {
-				status = "Completed"
-			}
-			log.Printf("- ID: %2d | Status: %-9s | Title: %s", 
-				todo.ID, 
-				status,
-				truncateString(todo.Title, 30))
+			fetched = append(fetched, *todo)
 		}
+		log.Printf("\n%s", FormatTodosTable(fetched))
 		
 		// Print any errors
 		if err != nil {
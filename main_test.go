@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// settledGoroutines waits for goroutines that are winding down to exit and
+// returns how many remain, polling until the count drops to want or a second
+// passes
+func settledGoroutines(want int) int {
+	deadline := time.Now().Add(time.Second)
+	n := runtime.NumGoroutine()
+	for n > want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		n = runtime.NumGoroutine()
+	}
+	return n
+}
+
+func TestFetchMultipleTodosCancellationDoesNotLeak(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		if _, err := fetchMultipleTodos(ctx, multiFetchOptions{}, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10); err == nil {
+			t.Fatal("cancelled batch succeeded")
+		}
+		cancel()
+	}
+	if after := settledGoroutines(before); after > before {
+		t.Errorf("goroutines grew from %d to %d over 50 cancelled batches", before, after)
+	}
+}
+
+func TestFetchResourcesCancellationDoesNotLeak(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Never answer within the test's cancellation window
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	// Warm up so the connection pool's goroutines are counted in the baseline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	FetchResources[Todo](ctx, c, "todos", []int{1, 2, 3, 4}, 4)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		if _, errs := FetchResources[Todo](ctx, c, "todos", []int{1, 2, 3, 4}, 4); len(errs) != 4 {
+			t.Fatalf("got %d failures, want every fetch cancelled", len(errs))
+		}
+		cancel()
+	}
+	if after := settledGoroutines(before); after > before {
+		t.Errorf("goroutines grew from %d to %d over 50 cancelled batches", before, after)
+	}
+}
+
+func TestFetchMultipleTodosPerRequestTimeout(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+
+	// Each fetch's artificial delay far outlasts the per-request timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	todos, err := fetchMultipleTodos(ctx, multiFetchOptions{perRequestTimeout: 10 * time.Millisecond}, 1, 2, 3)
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("batch took %v, want each fetch cut off after 10ms", took)
+	}
+	if todos != nil || !errors.Is(err, ErrAllFailed) {
+		t.Fatalf("got %v, %v; want every fetch to fail", todos, err)
+	}
+	if !strings.Contains(err.Error(), "request timed out after 10ms") || ctx.Err() != nil {
+		t.Errorf("error %q should report the per-request timeout, not the batch deadline", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %q doesn't wrap context.DeadlineExceeded", err)
+	}
+}
+
+// perIDErrors returns the per-todo errors fetchMultipleTodos joined into err
+func perIDErrors(err error) []error {
+	var joined interface{ Unwrap() []error }
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		if errors.As(e, &joined) {
+			return joined.Unwrap()
+		}
+	}
+	return nil
+}
+
+func TestFetchMultipleTodosFailFast(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+
+	// One fetch at a time, so todo 1 timing out stops the rest before they start
+	opts := multiFetchOptions{perRequestTimeout: 10 * time.Millisecond, maxConc: 1, failFast: true}
+	_, err := fetchMultipleTodos(context.Background(), opts, 1, 2, 3)
+	if !errors.Is(err, ErrAllFailed) {
+		t.Fatalf("got %v, want every fetch to fail", err)
+	}
+	errs := perIDErrors(err)
+	if len(errs) != 3 {
+		t.Fatalf("got %d per-todo errors, want 3: %v", len(errs), err)
+	}
+	var stopped *FailFastError
+	if errors.As(errs[0], &stopped) {
+		t.Errorf("the triggering failure is reported as stopped: %v", errs[0])
+	}
+	for _, e := range errs[1:] {
+		if !errors.As(e, &stopped) || stopped.ID != 1 || !errors.Is(e, context.DeadlineExceeded) {
+			t.Errorf("%v should carry todo 1's timeout as a *FailFastError", e)
+		}
+	}
+}
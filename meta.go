@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// cacheHeader marks responses served by WithDiskCache
+const cacheHeader = "X-Cache"
+
+// ResponseMeta describes the HTTP response behind a fetch
+type ResponseMeta struct {
+	// URL is the URL that answered, after any redirects
+	URL        string
+	StatusCode int
+	// ContentLength is the body size the server declared, or -1 when unknown
+	ContentLength int64
+	// FromCache is set when the response was served by WithDiskCache
+	FromCache bool
+}
+
+// newResponseMeta describes resp
+func newResponseMeta(resp *http.Response) ResponseMeta {
+	meta := ResponseMeta{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		FromCache:     resp.Header.Get(cacheHeader) == "HIT",
+	}
+	if resp.Request != nil {
+		meta.URL = resp.Request.URL.String()
+	}
+	return meta
+}
+
+// FetchResourceWithMeta is FetchResource also describing the response that
+// answered the final attempt. The metadata is filled in for error responses
+// too, and left zero when no response was received.
+func FetchResourceWithMeta[T any](ctx context.Context, c *Client, resource string, id int) (*T, ResponseMeta, error) {
+	var meta ResponseMeta
+	res := fetchResultMeta[T](ctx, c, resource, id, &meta, newCallOptions("", nil))
+	return res.Value, meta, res.Err
+}
+
+// FetchTodoWithMeta is FetchTodo also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) FetchTodoWithMeta(ctx context.Context, id int) (*Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	res := fetchResultMeta[Todo](ctx, c, "todos", id, &meta, newCallOptions("FetchTodoWithMeta", nil))
+	return res.Value, meta, res.Err
+}
+
+// FetchAllTodosWithMeta is FetchAllTodos also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) FetchAllTodosWithMeta(ctx context.Context) ([]Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	todos, err := c.fetchAllTodos(ctx, &meta, "FetchAllTodosWithMeta")
+	return todos, meta, err
+}
+
+// FetchTodosPageWithMeta is FetchTodosPage also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) FetchTodosPageWithMeta(ctx context.Context, page, pageSize int) ([]Todo, int, ResponseMeta, error) {
+	var meta ResponseMeta
+	todos, total, err := c.fetchTodosPage(ctx, page, pageSize, &meta, "FetchTodosPageWithMeta")
+	return todos, total, meta, err
+}
+
+// FetchTodosSinceWithMeta is FetchTodosSince also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) FetchTodosSinceWithMeta(ctx context.Context, afterID, limit int) ([]Todo, int, ResponseMeta, error) {
+	var meta ResponseMeta
+	todos, next, err := c.fetchTodosSince(ctx, afterID, limit, &meta, "FetchTodosSinceWithMeta")
+	return todos, next, meta, err
+}
+
+// FetchTodosFilteredWithMeta is FetchTodosFiltered also describing the
+// response, as FetchResourceWithMeta does. It covers FetchTodosForUser,
+// FetchCompletedTodos and FetchPendingTodos, which are filters too.
+func (c *Client) FetchTodosFilteredWithMeta(ctx context.Context, f TodoFilter) ([]Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	todos, err := c.fetchTodosFiltered(ctx, f, &meta, "FetchTodosFilteredWithMeta")
+	return todos, meta, err
+}
+
+// CreateTodoWithMeta is CreateTodo also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) CreateTodoWithMeta(ctx context.Context, todo Todo, opts ...CallOption) (*Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	created, err := c.createTodo(ctx, todo, &meta, newCallOptions("CreateTodoWithMeta", opts))
+	return created, meta, err
+}
+
+// UpdateTodoWithMeta is UpdateTodo also describing the response, as
+// FetchResourceWithMeta does
+func (c *Client) UpdateTodoWithMeta(ctx context.Context, todo Todo, opts ...CallOption) (*Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	updated, err := c.updateTodo(ctx, todo, &meta, newCallOptions("UpdateTodoWithMeta", opts))
+	return updated, meta, err
+}
+
+// UpsertTodoWithMeta is UpsertTodo also describing the response it ended
+// with: the create's when WithUpsertFallback replaced a missing todo
+func (c *Client) UpsertTodoWithMeta(ctx context.Context, todo Todo) (*Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	upserted, err := c.upsertTodo(ctx, todo, &meta, "UpsertTodoWithMeta")
+	return upserted, meta, err
+}
+
+// CreateTodosBatchWithMeta is CreateTodosBatch also describing the response,
+// as FetchResourceWithMeta does
+func (c *Client) CreateTodosBatchWithMeta(ctx context.Context, todos []Todo) ([]Todo, ResponseMeta, error) {
+	var meta ResponseMeta
+	created, err := c.createTodosBatch(ctx, todos, &meta, "CreateTodosBatchWithMeta")
+	return created, meta, err
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithMetaVariantsDescribeResponse(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body := `[{"id":1}]`
+		status := http.StatusOK
+		switch {
+		case r.Method == http.MethodPost:
+			body, status = `{"id":201}`, http.StatusCreated
+		case r.Method == http.MethodPut:
+			body = `{"id":1}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		call   func() (ResponseMeta, error)
+		status int
+		path   string
+	}{
+		{"FetchTodosPageWithMeta", func() (ResponseMeta, error) {
+			_, _, meta, err := c.FetchTodosPageWithMeta(ctx, 2, 10)
+			return meta, err
+		}, http.StatusOK, "/todos?_page=2&_limit=10"},
+		{"FetchTodosSinceWithMeta", func() (ResponseMeta, error) {
+			_, _, meta, err := c.FetchTodosSinceWithMeta(ctx, 0, 5)
+			return meta, err
+		}, http.StatusOK, "/todos?_sort=id&_start=0&_limit=5"},
+		{"FetchTodosFilteredWithMeta", func() (ResponseMeta, error) {
+			_, meta, err := c.FetchTodosFilteredWithMeta(ctx, TodoFilter{UserID: 3})
+			return meta, err
+		}, http.StatusOK, "/todos?userId=3"},
+		{"CreateTodoWithMeta", func() (ResponseMeta, error) {
+			_, meta, err := c.CreateTodoWithMeta(ctx, Todo{Title: "new"})
+			return meta, err
+		}, http.StatusCreated, "/todos"},
+		{"UpdateTodoWithMeta", func() (ResponseMeta, error) {
+			_, meta, err := c.UpdateTodoWithMeta(ctx, Todo{ID: 1, Title: "changed"})
+			return meta, err
+		}, http.StatusOK, "/todos/1"},
+		{"UpsertTodoWithMeta", func() (ResponseMeta, error) {
+			_, meta, err := c.UpsertTodoWithMeta(ctx, Todo{ID: 1})
+			return meta, err
+		}, http.StatusOK, "/todos/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := tt.call()
+			if err != nil {
+				t.Fatalf("call failed: %v", err)
+			}
+			if meta.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", meta.StatusCode, tt.status)
+			}
+			if !strings.HasSuffix(meta.URL, tt.path) {
+				t.Errorf("URL = %q, want it to end in %s", meta.URL, tt.path)
+			}
+			if meta.ContentLength <= 0 {
+				t.Errorf("ContentLength = %d, want the declared body size", meta.ContentLength)
+			}
+		})
+	}
+}
+
+func TestCreateTodosBatchWithMeta(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`[{"id":201},{"id":202}]`))
+	})
+
+	created, meta, err := c.CreateTodosBatchWithMeta(context.Background(), []Todo{{Title: "a"}, {Title: "b"}})
+	if err != nil {
+		t.Fatalf("CreateTodosBatchWithMeta: %v", err)
+	}
+	if len(created) != 2 || meta.StatusCode != http.StatusCreated {
+		t.Errorf("got %d todos with status %d, want 2 with 201", len(created), meta.StatusCode)
+	}
+}
+
+func TestUpsertTodoWithMetaDescribesFallbackCreate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":201}`))
+	}, WithUpsertFallback())
+
+	todo, meta, err := c.UpsertTodoWithMeta(context.Background(), Todo{ID: 99, Title: "gone"})
+	if err != nil {
+		t.Fatalf("UpsertTodoWithMeta: %v", err)
+	}
+	if todo.ID != 201 || meta.StatusCode != http.StatusCreated || !strings.HasSuffix(meta.URL, "/todos") {
+		t.Errorf("got todo %d with %+v, want the create's response", todo.ID, meta)
+	}
+}
+
+func TestWithMetaDescribesErrorResponse(t *testing.T) {
+	c, _ := statusServer(t, http.StatusNotFound)
+
+	_, meta, err := c.FetchTodosFilteredWithMeta(context.Background(), TodoFilter{UserID: 1})
+	if err == nil {
+		t.Fatal("FetchTodosFilteredWithMeta succeeded against a failing server")
+	}
+	if meta.StatusCode != http.StatusNotFound {
+		t.Errorf("StatusCode = %d, want 404", meta.StatusCode)
+	}
+}
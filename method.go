@@ -0,0 +1,18 @@
+package main
+
+import "time"
+
+// WithMethodTimeout sets the default timeout for calls made through one Client
+// method, named as in Go (e.g. "CreateTodo"), taking precedence over
+// WithResourceTimeout and WithTimeout when the context has no deadline. It
+// applies to that method only, including every request it makes, and not to
+// methods built on the same calls, so a timeout for UpdateTodo doesn't affect
+// UpsertTodo or UpdateTodoWithMeta.
+func WithMethodTimeout(method string, d time.Duration) Option {
+	return func(c *Client) {
+		if c.methodTimeouts == nil {
+			c.methodTimeouts = make(map[string]time.Duration)
+		}
+		c.methodTimeouts[method] = d
+	}
+}
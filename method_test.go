@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMethodTimeoutAppliesToThatMethodOnly(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client giving up once the body is read
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}, WithTimeout(300*time.Millisecond), WithMethodTimeout("CreateTodo", 30*time.Millisecond), WithMethodTimeout("FetchTodosForUser", 30*time.Millisecond))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		want time.Duration
+	}{
+		{"CreateTodo", func() error {
+			_, err := c.CreateTodo(ctx, Todo{Title: "a"})
+			return err
+		}, 30 * time.Millisecond},
+		{"UpsertTodo creating", func() error {
+			_, err := c.UpsertTodo(ctx, Todo{Title: "a"})
+			return err
+		}, 300 * time.Millisecond},
+		{"CreateTodoWithMeta", func() error {
+			_, _, err := c.CreateTodoWithMeta(ctx, Todo{Title: "a"})
+			return err
+		}, 300 * time.Millisecond},
+		{"FetchTodosForUser", func() error {
+			_, err := c.FetchTodosForUser(ctx, 1)
+			return err
+		}, 30 * time.Millisecond},
+		{"FetchTodosFiltered", func() error {
+			_, err := c.FetchTodosFiltered(ctx, TodoFilter{UserID: 1})
+			return err
+		}, 300 * time.Millisecond},
+		{"FetchTodosForUsers", func() error {
+			_, err := c.FetchTodosForUsers(ctx, []int{1})
+			return err
+		}, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := tt.call()
+			took := time.Since(start)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v, want a deadline error", err)
+			}
+			if took < tt.want || took > tt.want+150*time.Millisecond {
+				t.Errorf("timed out after %v, want %v", took, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodTimeoutYieldsToContextDeadline(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, WithMethodTimeout("FetchTodo", time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.FetchTodo(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want a deadline error", err)
+	}
+	if took := time.Since(start); took > 200*time.Millisecond {
+		t.Errorf("took %v, want the context's 30ms deadline to apply", took)
+	}
+}
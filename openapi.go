@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// BodyParam is the Call parameter holding an operation's JSON request body
+const BodyParam = "$body"
+
+// ErrUnknownOperation is returned by Call for an operation ID the spec set by
+// WithOpenAPISpec doesn't define
+var ErrUnknownOperation = errors.New("unknown OpenAPI operation")
+
+// WithOpenAPISpec loads an OpenAPI 3 document in JSON form, letting Call invoke
+// any operation it defines by operation ID. Paths in the spec are relative to
+// the base URL. Only path, query and header parameters and JSON request bodies
+// are supported; parameters may be $refs to components/parameters.
+func WithOpenAPISpec(spec []byte) Option {
+	return func(c *Client) {
+		ops, err := parseOpenAPISpec(spec)
+		if err != nil {
+			c.specErr = fmt.Errorf("invalid OpenAPI spec: %w", err)
+			return
+		}
+		c.operations = ops
+	}
+}
+
+// openAPIOperation is the part of an OpenAPI operation Call needs
+type openAPIOperation struct {
+	method  string
+	path    string
+	params  []openAPIParam
+	hasBody bool
+}
+
+// openAPIParam is a parameter of an OpenAPI operation
+type openAPIParam struct {
+	Ref      string `json:"$ref"`
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+}
+
+// openAPIMethods are the path item keys that hold operations
+var openAPIMethods = map[string]string{
+	"get":     http.MethodGet,
+	"put":     http.MethodPut,
+	"post":    http.MethodPost,
+	"delete":  http.MethodDelete,
+	"options": http.MethodOptions,
+	"head":    http.MethodHead,
+	"patch":   http.MethodPatch,
+	"trace":   http.MethodTrace,
+}
+
+// parseOpenAPISpec indexes the operations in spec by operation ID
+func parseOpenAPISpec(spec []byte) (map[string]openAPIOperation, error) {
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Parameters map[string]openAPIParam `json:"parameters"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	resolve := func(params []openAPIParam) ([]openAPIParam, error) {
+		resolved := make([]openAPIParam, 0, len(params))
+		for _, p := range params {
+			if p.Ref != "" {
+				name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+				ref, found := doc.Components.Parameters[name]
+				if !ok || !found {
+					return nil, fmt.Errorf("unresolvable parameter $ref %q", p.Ref)
+				}
+				p = ref
+			}
+			if p.Name == "" {
+				return nil, errors.New("parameter without a name")
+			}
+			resolved = append(resolved, p)
+		}
+		return resolved, nil
+	}
+
+	ops := make(map[string]openAPIOperation)
+	for path, item := range doc.Paths {
+		// Parameters on the path item apply to each of its operations
+		var shared []openAPIParam
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("path %s: %w", path, err)
+			}
+		}
+		for key, raw := range item {
+			method, ok := openAPIMethods[key]
+			if !ok {
+				continue
+			}
+			var op struct {
+				OperationID string          `json:"operationId"`
+				Parameters  []openAPIParam  `json:"parameters"`
+				RequestBody json.RawMessage `json:"requestBody"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if op.OperationID == "" {
+				continue
+			}
+			if _, dup := ops[op.OperationID]; dup {
+				return nil, fmt.Errorf("duplicate operationId %q", op.OperationID)
+			}
+			params, err := resolve(append(append([]openAPIParam(nil), shared...), op.Parameters...))
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			ops[op.OperationID] = openAPIOperation{
+				method:  method,
+				path:    path,
+				params:  params,
+				hasBody: len(op.RequestBody) > 0,
+			}
+		}
+	}
+	return ops, nil
+}
+
+// Call invokes the operation with the given ID from the spec loaded by
+// WithOpenAPISpec and returns the raw response body. params holds the
+// operation's parameters by name, formatted with fmt's %v; slices become
+// repeated query parameters. The JSON request body, if any, goes under
+// BodyParam. Unknown or missing required parameters are rejected before
+// anything is sent.
+func (c *Client) Call(ctx context.Context, operationID string, params map[string]any) ([]byte, error) {
+	op, ok := c.operations[operationID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownOperation, operationID)
+	}
+
+	path := op.path
+	query := url.Values{}
+	header := http.Header{}
+	used := map[string]bool{}
+	for _, p := range op.params {
+		v, ok := params[p.Name]
+		if !ok {
+			if p.Required || p.In == "path" {
+				return nil, fmt.Errorf("%s: missing required %s parameter %q", operationID, p.In, p.Name)
+			}
+			continue
+		}
+		used[p.Name] = true
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(fmt.Sprint(v)))
+		case "query":
+			for _, value := range paramValues(v) {
+				query.Add(p.Name, value)
+			}
+		case "header":
+			header.Set(p.Name, fmt.Sprint(v))
+		default:
+			return nil, fmt.Errorf("%s: unsupported %s parameter %q", operationID, p.In, p.Name)
+		}
+	}
+	body, hasBody := params[BodyParam]
+	if hasBody && !op.hasBody {
+		return nil, fmt.Errorf("%s: operation takes no request body", operationID)
+	}
+	var unknown []string
+	for name := range params {
+		if !used[name] && name != BodyParam {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%s: unknown parameters %s", operationID, strings.Join(unknown, ", "))
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	// The first path segment names the resource, as in /todos/{id}
+	resource, _, _ := strings.Cut(strings.TrimPrefix(op.path, "/"), "/")
+	var data []byte
+	err := c.do(ctx, apiRequest{
+		method:   op.method,
+		resource: resource,
+		path:     path,
+		in:       body,
+		header:   header,
+		caller:   "Call",
+		decode: func(r io.Reader) error {
+			var err error
+			data, err = io.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("error reading response: %w", err)
+			}
+			return nil
+		},
+	})
+	return data, err
+}
+
+// paramValues formats a parameter value, expanding slices into one value per element
+func paramValues(v any) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{fmt.Sprint(v)}
+	}
+	values := make([]string, rv.Len())
+	for i := range values {
+		values[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return values
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestPatchTodoContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+		body string
+	}{
+		{"merge patch", nil, "application/merge-patch+json", `{"completed":null,"title":"new"}`},
+		{"JSON patch", []Option{WithJSONPatch()}, "application/json-patch+json", `[{"op":"replace","path":"/title","value":"new"},{"op":"remove","path":"/completed"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contentType, body string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPatch || r.URL.Path != "/todos/5" {
+					t.Errorf("got %s %s, want PATCH /todos/5", r.Method, r.URL.Path)
+				}
+				contentType = r.Header.Get("Content-Type")
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				writeJSON(w, `{"id":5,"title":"new"}`)
+			}, tt.opts...)
+
+			ops := []PatchOp{{Op: "replace", Path: "/title", Value: "new"}, {Op: "remove", Path: "/completed"}}
+			todo, err := c.PatchTodo(context.Background(), 5, ops)
+			if err != nil {
+				t.Fatalf("PatchTodo: %v", err)
+			}
+			if todo.Title != "new" {
+				t.Errorf("decoded title %q", todo.Title)
+			}
+			if contentType != tt.want {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.want)
+			}
+			if body != tt.body {
+				t.Errorf("body = %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
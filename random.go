@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithRand sets the random source used for sampling and jitter decisions.
+// Seeding it makes those decisions reproducible in tests.
+func WithRand(r *rand.Rand) Option {
+	return func(c *Client) {
+		c.rand = newLockedRand(r)
+	}
+}
+
+// lockedRand makes a *rand.Rand safe for concurrent use
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newLockedRand(r *rand.Rand) *lockedRand {
+	return &lockedRand{r: r}
+}
+
+// Float64 returns a number in [0.0, 1.0)
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// Duration returns a duration in [0, max), or 0 when max isn't positive
+func (l *lockedRand) Duration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Duration(l.r.Int63n(int64(max)))
+}
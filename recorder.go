@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestBody    []byte      `json:"requestBody,omitempty"`
+	StatusCode     int         `json:"statusCode"`
+	ResponseHeader http.Header `json:"responseHeader,omitempty"`
+	ResponseBody   []byte      `json:"responseBody,omitempty"`
+	// Truncated marks a response body cut short by WithRecorderBodyLimit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// key returns the value interactions are matched by during replay
+func (in *Interaction) key() string {
+	return in.Method + " " + in.URL + "\n" + string(in.RequestBody)
+}
+
+// response rebuilds the recorded response as an answer to req
+func (in *Interaction) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.ResponseHeader.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(in.ResponseBody)),
+		ContentLength: int64(len(in.ResponseBody)),
+		Request:       req,
+	}
+}
+
+// WithRecorder captures every request/response pair to the JSON file at path
+func WithRecorder(path string) Option {
+	return func(c *Client) {
+		c.transports = append(c.transports, func(next http.RoundTripper) http.RoundTripper {
+			return &recordingTransport{next: next, path: path, bodyLimit: c.recordBodyLimit}
+		})
+	}
+}
+
+// WithRecorderBodyLimit keeps at most n bytes of each response body recorded by
+// WithRecorder, marking longer ones as truncated. Truncated interactions can't
+// be replayed: WithReplay fails those requests rather than serve partial
+// bodies. Request bodies are always recorded in full since replay matches on
+// them. The caller still receives the complete response.
+func WithRecorderBodyLimit(n int64) Option {
+	return func(c *Client) {
+		c.recordBodyLimit = n
+	}
+}
+
+// WithReplay serves responses from the recording at path instead of the network.
+// Requests are matched by method, URL and body; repeated requests are served in
+// recorded order.
+func WithReplay(path string) Option {
+	return func(c *Client) {
+		c.transports = append(c.transports, func(http.RoundTripper) http.RoundTripper {
+			return &replayTransport{path: path}
+		})
+	}
+}
+
+// readRequestBody reads req's body and replaces it so it can still be sent
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// recordingTransport appends each round trip to a cassette file
+type recordingTransport struct {
+	next http.RoundTripper
+	path string
+	// bodyLimit caps recorded response bodies when positive
+	bodyLimit int64
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading request body: %w", err)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: error reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestBody:    reqBody,
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		ResponseBody:   respBody,
+	}
+	if t.bodyLimit > 0 && int64(len(respBody)) > t.bodyLimit {
+		in.ResponseBody = respBody[:t.bodyLimit]
+		in.Truncated = true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interactions = append(t.interactions, in)
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save rewrites the cassette with everything recorded so far
+func (t *recordingTransport) save() error {
+	data, err := json.MarshalIndent(t.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("recorder: error encoding recording: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("recorder: error writing recording: %w", err)
+	}
+	return nil
+}
+
+// replayTransport answers requests from a cassette file without touching the network
+type replayTransport struct {
+	path string
+
+	once    sync.Once
+	loadErr error
+
+	mu      sync.Mutex
+	pending map[string][]Interaction
+}
+
+// load reads the cassette on first use
+func (t *replayTransport) load() error {
+	t.once.Do(func() {
+		data, err := os.ReadFile(t.path)
+		if err != nil {
+			t.loadErr = fmt.Errorf("replay: error reading recording: %w", err)
+			return
+		}
+		var interactions []Interaction
+		if err := json.Unmarshal(data, &interactions); err != nil {
+			t.loadErr = fmt.Errorf("replay: error decoding recording: %w", err)
+			return
+		}
+		t.pending = make(map[string][]Interaction)
+		for _, in := range interactions {
+			t.pending[in.key()] = append(t.pending[in.key()], in)
+		}
+	})
+	return t.loadErr
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("replay: error reading request body: %w", err)
+	}
+	match := Interaction{Method: req.Method, URL: req.URL.String(), RequestBody: reqBody}
+
+	t.mu.Lock()
+	queue := t.pending[match.key()]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recorded interaction for %s %s", req.Method, req.URL)
+	}
+	in := queue[0]
+	// Keep serving the last interaction once earlier ones are used up
+	if len(queue) > 1 {
+		t.pending[match.key()] = queue[1:]
+	}
+	t.mu.Unlock()
+
+	if in.Truncated {
+		return nil, fmt.Errorf("replay: recorded response for %s %s was truncated and can't be replayed", req.Method, req.URL)
+	}
+	return in.response(req), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of fetching one record, with the metadata of the call
+type Result[T any] struct {
+	ID    int
+	Value *T
+	Err   error
+	// Latency is how long the call took, retries and backoff included
+	Latency time.Duration
+	// Attempts is how many requests were sent, counting fallback base URLs
+	Attempts int
+}
+
+// fetchResult fetches /{resource}/{id} into a new T for the Client method
+// caller and reports how the call went
+func fetchResult[T any](ctx context.Context, c *Client, resource string, id int, caller string) Result[T] {
+	return fetchResultMeta[T](ctx, c, resource, id, nil, newCallOptions(caller, nil))
+}
+
+// fetchResultMeta is fetchResult with per-call options, describing the
+// response in meta when non-nil
+func fetchResultMeta[T any](ctx context.Context, c *Client, resource string, id int, meta *ResponseMeta, o callOptions) Result[T] {
+	res := Result[T]{ID: id}
+	var v T
+	r := apiRequest{
+		method:   http.MethodGet,
+		resource: resource,
+		path:     fmt.Sprintf("/%s/%d", resource, id),
+		id:       id,
+		out:      &v,
+		attempts: &res.Attempts,
+		meta:     meta,
+		retry:    o.retry,
+		caller:   o.caller,
+	}
+	start := time.Now()
+	res.Err = c.do(ctx, r)
+	res.Latency = time.Since(start)
+	if res.Err == nil {
+		res.Value, res.Err = transform(ctx, c, &v)
+	}
+	return res
+}
+
+// FetchResourceResults is FetchResources reporting one Result per distinct ID,
+// in input order, whether it succeeded, failed or never started
+func FetchResourceResults[T any](ctx context.Context, c *Client, resource string, ids []int, maxConc int) []Result[T] {
+	unique := dedupeIDs(ids)
+	index := make(map[int]int, len(unique))
+	for i, id := range unique {
+		index[id] = i
+	}
+	results := make([]Result[T], len(unique))
+	var mu sync.Mutex
+
+	_, errs := fetchConcurrently(ctx, unique, c.batchOptions(maxConc), func(ctx context.Context, id int) (*T, error) {
+		res := fetchResult[T](ctx, c, resource, id, "")
+		mu.Lock()
+		results[index[id]] = res
+		mu.Unlock()
+		return res.Value, res.Err
+	})
+
+	// Errors may have been annotated by the batch, and IDs that never started
+	// have no result yet
+	for id, err := range errs {
+		results[index[id]].ID = id
+		results[index[id]].Err = err
+	}
+	return results
+}
+
+// FetchTodosSink fetches each distinct ID with the client's usual batch
+// concurrency and passes every outcome to sink as it completes, so memory
+// doesn't grow with the batch. sink is never called concurrently, but it is
+// called from the worker goroutines, so a slow sink holds up the batch. IDs
+// that never started because ctx ended are reported after the others, with the
+// context's cause. FetchTodosSink returns once sink has seen every ID.
+func (c *Client) FetchTodosSink(ctx context.Context, ids []int, sink func(Result[Todo])) {
+	var mu sync.Mutex
+	failed := make(map[int]bool)
+	emit := func(res Result[Todo]) {
+		mu.Lock()
+		defer mu.Unlock()
+		if res.Err != nil {
+			failed[res.ID] = true
+		}
+		sink(res)
+	}
+
+	_, errs := fetchConcurrently(ctx, ids, c.batchOptions(defaultBatchConcurrency), func(ctx context.Context, id int) (*Todo, error) {
+		res := fetchResult[Todo](ctx, c, "todos", id, "FetchTodosSink")
+		emit(res)
+		// The value has been handed over, so the batch needn't keep it
+		return nil, res.Err
+	})
+
+	for _, id := range dedupeIDs(ids) {
+		if err, ok := errs[id]; ok && !failed[id] {
+			sink(Result[Todo]{ID: id, Err: err})
+		}
+	}
+}
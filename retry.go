@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried with exponential backoff
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first; 0 or 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry, doubled for each one after
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between attempts when non-zero
+	MaxDelay time.Duration
+}
+
+// backoff returns the delay to wait after the given failed attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// WithRetry retries transport failures, 5xx and 429 responses according to p.
+// A longer wait requested by the server's Retry-After header takes precedence
+// over the policy's backoff.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// WithCallRetry makes a single call retry and back off per p instead of the
+// client's WithRetry and WithRetryByStatus settings, e.g. p.MaxAttempts of 1
+// for a one-off call that mustn't be retried. p's attempt budget also caps
+// WithHedging for the call at p.MaxAttempts-1 extra requests per attempt, so a
+// call that mustn't be retried isn't duplicated either.
+func WithCallRetry(p RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = &p
+	}
+}
+
+// callRetryKey is the context key carrying a WithCallRetry policy to the transports
+type callRetryKey struct{}
+
+// callRetry returns the WithCallRetry policy of the call ctx belongs to, if any
+func callRetry(ctx context.Context) (RetryPolicy, bool) {
+	p, ok := ctx.Value(callRetryKey{}).(RetryPolicy)
+	return p, ok
+}
+
+// WithOnRetry registers fn to be called before each backoff sleep with the attempt
+// that failed, its error and the delay before the next attempt. fn runs on the
+// request's goroutine, so it must return promptly.
+func WithOnRetry(fn func(attempt int, err error, nextDelay time.Duration)) Option {
+	return func(c *Client) {
+		c.onRetry = fn
+	}
+}
+
+// WithRetryByStatus sets the maximum number of attempts for specific response
+// status codes, overriding the retry policy for them; e.g. {503: 5, 500: 1}
+// retries 503s up to four times and never retries 500s. Unlisted codes follow
+// the policy set by WithRetry.
+func WithRetryByStatus(maxAttempts map[int]int) Option {
+	return func(c *Client) {
+		c.retryByStatus = make(map[int]int, len(maxAttempts))
+		for status, n := range maxAttempts {
+			c.retryByStatus[status] = n
+		}
+	}
+}
+
+// shouldRetry reports whether another attempt should follow the failed attempt.
+// A per-call policy, when set, replaces both WithRetry and WithRetryByStatus.
+func (c *Client) shouldRetry(ctx context.Context, call *RetryPolicy, attempt int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if call != nil {
+		return attempt < call.MaxAttempts && c.isRetryable(ctx, err)
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		if n, ok := c.retryByStatus[statusErr.StatusCode]; ok {
+			return attempt < n
+		}
+	}
+	return attempt < c.retry.MaxAttempts && c.isRetryable(ctx, err)
+}
+
+// ErrMaxDurationExceeded is returned, wrapping the last attempt's error, when a
+// request runs past the cap set by WithMaxTotalDuration
+var ErrMaxDurationExceeded = errors.New("maximum request duration exceeded")
+
+// WithMaxTotalDuration caps the whole of each call, all attempts and backoff
+// included, at d regardless of per-attempt timeouts
+func WithMaxTotalDuration(d time.Duration) Option {
+	return func(c *Client) {
+		c.maxTotalDuration = d
+	}
+}
+
+// retryAfterFormats are the HTTP-date layouts accepted in Retry-After, starting
+// with the standard IMF-fixdate and followed by formats seen from real servers
+var retryAfterFormats = []string{
+	http.TimeFormat,
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+	time.RFC3339,
+}
+
+// parseRetryAfter converts a Retry-After value, either an HTTP-date or a number
+// of seconds, into a wait relative to now. Dates in the past mean no wait.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	for _, layout := range retryAfterFormats {
+		if t, err := time.Parse(layout, value); err == nil {
+			if d := t.Sub(now); d > 0 {
+				return d, true
+			}
+			return 0, true
+		}
+	}
+	// Not a date, so treat it as seconds, tolerating fractions
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// ErrorClassifier decides whether a failed attempt is worth retrying. resp is
+// the error response, or nil when no response was received; its Body holds
+// only what WithErrorBodyCapture captured.
+type ErrorClassifier func(resp *http.Response, err error) bool
+
+// WithErrorClassifier replaces DefaultErrorClassifier in every retry decision,
+// including whether to move on to a fallback base URL. Attempts are still
+// bounded by the retry policy, and nothing is retried once the context is done.
+func WithErrorClassifier(fn ErrorClassifier) Option {
+	return func(c *Client) {
+		c.errorClassifier = fn
+	}
+}
+
+// DefaultErrorClassifier retries transport failures, 5xx and 429 responses.
+// DNS lookup failures are retried whether or not the resolver calls them
+// temporary, since one that isn't ready yet, as during container startup,
+// reports missing hosts that resolve moments later.
+func DefaultErrorClassifier(resp *http.Response, err error) bool {
+	if resp != nil {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	// Transport failures from http.Client.Do are always *url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// isRetryable reports whether err from an attempt is worth retrying
+func (c *Client) isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if c.errorClassifier == nil {
+		return DefaultErrorClassifier(nil, err)
+	}
+	var resp *http.Response
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.resp != nil {
+		// Give each decision a fresh copy reading the captured body
+		classified := *statusErr.resp
+		classified.Body = io.NopCloser(bytes.NewReader(statusErr.Body))
+		resp = &classified
+	}
+	return c.errorClassifier(resp, err)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer answers every request with status, counting the requests
+func statusServer(t *testing.T, status int, opts ...Option) (*Client, *atomic.Int32) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}, opts...)
+	return c, &calls
+}
+
+func TestRetryByStatus(t *testing.T) {
+	byStatus := WithRetryByStatus(map[int]int{503: 5, 500: 1, 400: 3})
+	policy := WithRetry(RetryPolicy{MaxAttempts: 2})
+	tests := []struct {
+		name   string
+		status int
+		want   int32
+	}{
+		{"listed status retried up to its limit", 503, 5},
+		{"listed status with one attempt never retried", 500, 1},
+		{"listed status overrides the classifier", 400, 3},
+		{"unlisted retryable status follows the policy", 502, 2},
+		{"unlisted non-retryable status isn't retried", 404, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, calls := statusServer(t, tt.status, byStatus, policy)
+			if _, err := c.FetchTodo(context.Background(), 1); err == nil {
+				t.Fatal("FetchTodo succeeded against a failing server")
+			}
+			if got := calls.Load(); got != tt.want {
+				t.Errorf("got %d attempts, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(90 * time.Second)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"IMF-fixdate", later.Format(http.TimeFormat), 90 * time.Second, true},
+		{"RFC 1123", later.Format(time.RFC1123), 90 * time.Second, true},
+		{"RFC 1123 with numeric zone", later.In(time.FixedZone("", 2*3600)).Format(time.RFC1123Z), 90 * time.Second, true},
+		{"RFC 850", later.Format(time.RFC850), 90 * time.Second, true},
+		{"ANSI C", later.Format(time.ANSIC), 90 * time.Second, true},
+		{"RFC 3339", later.Format(time.RFC3339), 90 * time.Second, true},
+		{"date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"seconds", "120", 120 * time.Second, true},
+		{"fractional seconds", "1.5", 1500 * time.Millisecond, true},
+		{"padded", "  7 ", 7 * time.Second, true},
+		{"empty", "", 0, false},
+		{"negative", "-1", 0, false},
+		{"garbage", "soon", 0, false},
+		{"infinite", "Inf", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: parseRetryAfter(%q) = %v, %v; want %v, %v", tt.name, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCallRetryOverridesClientPolicy(t *testing.T) {
+	clientPolicy := []Option{
+		WithRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond}),
+		WithRetryByStatus(map[int]int{503: 4}),
+	}
+	tests := []struct {
+		name   string
+		status int
+		policy RetryPolicy
+		want   int32
+	}{
+		{"no retries beats WithRetry", 502, RetryPolicy{MaxAttempts: 1}, 1},
+		{"no retries beats WithRetryByStatus", 503, RetryPolicy{MaxAttempts: 1}, 1},
+		{"more retries beat WithRetry", 502, RetryPolicy{MaxAttempts: 7}, 7},
+		{"more retries beat WithRetryByStatus", 503, RetryPolicy{MaxAttempts: 6}, 6},
+		{"non-retryable status still isn't retried", 404, RetryPolicy{MaxAttempts: 3}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, calls := statusServer(t, tt.status, clientPolicy...)
+			if _, err := c.FetchTodo(context.Background(), 1, WithCallRetry(tt.policy)); err == nil {
+				t.Fatal("FetchTodo succeeded against a failing server")
+			}
+			if got := calls.Load(); got != tt.want {
+				t.Errorf("got %d attempts, want %d", got, tt.want)
+			}
+			// The override is for that call only
+			calls.Store(0)
+			c.FetchTodo(context.Background(), 1)
+			if got, want := calls.Load(), map[int]int32{502: 5, 503: 4, 404: 1}[tt.status]; got != want {
+				t.Errorf("next call made %d attempts, want the client's %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCallRetryOverridesBackoff(t *testing.T) {
+	var delays []time.Duration
+	c, _ := statusServer(t, http.StatusBadGateway,
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}),
+		WithOnRetry(func(attempt int, err error, nextDelay time.Duration) {
+			delays = append(delays, nextDelay)
+		}))
+
+	c.FetchTodo(context.Background(), 1, WithCallRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 3 * time.Millisecond}))
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Errorf("backoff delays = %v, want %v from the call's policy", delays, want)
+	}
+}
+
+func TestCallRetryCapsHedging(t *testing.T) {
+	var sent atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+		// Slow enough for every hedge to go out before the first answer
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		writeJSON(w, `{"id":1}`)
+	}, WithHedging(5*time.Millisecond, 2))
+
+	tests := []struct {
+		name string
+		opts []CallOption
+		want int32
+	}{
+		{"client hedging", nil, 3},
+		{"no retries sends no hedges", []CallOption{WithCallRetry(RetryPolicy{MaxAttempts: 1})}, 1},
+		{"hedges capped by the attempt budget", []CallOption{WithCallRetry(RetryPolicy{MaxAttempts: 2})}, 2},
+		{"bigger budget keeps the client's limit", []CallOption{WithCallRetry(RetryPolicy{MaxAttempts: 5})}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent.Store(0)
+			if _, err := c.FetchTodo(context.Background(), 1, tt.opts...); err != nil {
+				t.Fatalf("FetchTodo: %v", err)
+			}
+			if got := sent.Load(); got != tt.want {
+				t.Errorf("sent %d requests, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnRetryWithOnlyCallRetries(t *testing.T) {
+	var retries atomic.Int32
+	// The client doesn't retry by default, but its calls may
+	c, calls := statusServer(t, http.StatusBadGateway, WithOnRetry(func(attempt int, err error, nextDelay time.Duration) {
+		retries.Add(1)
+	}))
+
+	c.FetchTodo(context.Background(), 1, WithCallRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if calls.Load() != 3 || retries.Load() != 2 {
+		t.Errorf("made %d attempts and %d OnRetry calls, want 3 and 2", calls.Load(), retries.Load())
+	}
+}
+
+func TestMaxTotalDurationWithDeadlineGrace(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, WithMaxTotalDuration(100*time.Millisecond), WithDeadlineGrace(20*time.Millisecond))
+
+	t.Run("cap cut short by the grace", func(t *testing.T) {
+		_, err := c.FetchTodo(context.Background(), 1)
+		if !errors.Is(err, ErrMaxDurationExceeded) {
+			t.Errorf("got %v, want ErrMaxDurationExceeded", err)
+		}
+	})
+	t.Run("caller's tighter deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := c.FetchTodo(ctx, 1)
+		if errors.Is(err, ErrMaxDurationExceeded) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want a plain deadline error", err)
+		}
+	})
+}
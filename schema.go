@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// TodoJSONSchema returns a JSON Schema document describing the Todo type.
+// It is generated from the struct's fields and json tags so it can't drift.
+func TodoJSONSchema() []byte {
+	t := reflect.TypeOf(Todo{})
+	properties := make(map[string]any, t.NumField())
+	required := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = map[string]any{"type": jsonSchemaType(field.Type.Kind())}
+		required = append(required, name)
+	}
+
+	schema := map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      t.Name(),
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		// Only plain maps, slices and strings are marshaled above
+		panic(err)
+	}
+	return data
+}
+
+// jsonSchemaType maps a Go kind to the matching JSON Schema type name
+func jsonSchemaType(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// SchemaValidator checks raw JSON documents against a JSON Schema. The default,
+// from NewSchemaValidator, is backed by github.com/santhosh-tekuri/jsonschema;
+// implement it over another library and pass it to WithSchemaValidator to swap
+// that out.
+type SchemaValidator interface {
+	// Validate returns one message per violation, or none when doc is valid
+	Validate(doc []byte) []string
+}
+
+// ErrSchemaValidation is returned when a response doesn't match the configured schema
+type ErrSchemaValidation struct {
+	Violations []string
+}
+
+func (e *ErrSchemaValidation) Error() string {
+	return fmt.Sprintf("response failed schema validation: %s", strings.Join(e.Violations, "; "))
+}
+
+// WithResponseSchema validates every JSON object response, and each element of
+// array responses, against schema before decoding, using the validator from
+// NewSchemaValidator. An invalid schema makes NewClient fail.
+func WithResponseSchema(schema []byte) Option {
+	return func(c *Client) {
+		v, err := NewSchemaValidator(schema)
+		if err != nil {
+			c.schemaErr = fmt.Errorf("invalid response schema: %w", err)
+			return
+		}
+		c.schemaValidator = v
+	}
+}
+
+// WithSchemaValidator validates responses with v, as WithResponseSchema does
+func WithSchemaValidator(v SchemaValidator) Option {
+	return func(c *Client) {
+		c.schemaValidator = v
+	}
+}
+
+// schemaURL is the location the compiled schema is registered under
+const schemaURL = "response-schema.json"
+
+// errExternalRef rejects $refs to documents other than the schema itself
+var errExternalRef = errors.New("schemas may only $ref within themselves")
+
+// NewSchemaValidator compiles schema with github.com/santhosh-tekuri/jsonschema,
+// which supports every keyword of drafts 4 through 2020-12, picking the draft
+// from $schema and defaulting to 2020-12. $refs must stay within the schema:
+// nothing is fetched from files or the network.
+func NewSchemaValidator(schema []byte) (SchemaValidator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(noExternalRefs{})
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return &librarySchemaValidator{schema: compiled}, nil
+}
+
+// noExternalRefs is a jsonschema.URLLoader refusing to load anything
+type noExternalRefs struct{}
+
+func (noExternalRefs) Load(url string) (any, error) {
+	return nil, fmt.Errorf("%w: %s", errExternalRef, url)
+}
+
+// librarySchemaValidator is the default SchemaValidator
+type librarySchemaValidator struct {
+	schema *jsonschema.Schema
+}
+
+func (v *librarySchemaValidator) Validate(doc []byte) []string {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(doc))
+	if err != nil {
+		return []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	err = v.schema.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	var invalid *jsonschema.ValidationError
+	if !errors.As(err, &invalid) {
+		return []string{err.Error()}
+	}
+	violations := schemaViolations(*invalid.DetailedOutput(), nil)
+	if len(violations) == 0 {
+		violations = []string{invalid.Error()}
+	}
+	return violations
+}
+
+// schemaViolations appends the leaf errors of unit to violations; the units
+// above them only summarize their causes, as in "'anyOf' failed"
+func schemaViolations(unit jsonschema.OutputUnit, violations []string) []string {
+	if len(unit.Errors) == 0 {
+		if unit.Error != nil {
+			violations = append(violations, fmt.Sprintf("%s: %s", instancePath(unit.InstanceLocation), unit.Error))
+		}
+		return violations
+	}
+	for _, cause := range unit.Errors {
+		violations = schemaViolations(cause, violations)
+	}
+	return violations
+}
+
+// instancePath turns a JSON pointer into the $.a[0].b form used in violations
+func instancePath(pointer string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if isIndex(token) {
+			fmt.Fprintf(&b, "[%s]", token)
+		} else {
+			b.WriteString("." + token)
+		}
+	}
+	return b.String()
+}
+
+// isIndex reports whether a JSON pointer token is an array index
+func isIndex(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
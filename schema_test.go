@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSchemaValidatorEnforcesKeywords(t *testing.T) {
+	tests := []struct {
+		keyword string
+		valid   string
+		invalid string
+	}{
+		{`"enum":[1,2]`, `{"id":2}`, `{"id":3}`},
+		{`"minimum":1`, `{"id":1}`, `{"id":0}`},
+		{`"anyOf":[{"type":"integer"},{"type":"string"}]`, `{"id":"a"}`, `{"id":true}`},
+		{`"$ref":"#/$defs/positive"`, `{"id":5}`, `{"id":-5}`},
+	}
+	for _, tt := range tests {
+		v, err := NewSchemaValidator([]byte(`{
+			"$defs": {"positive": {"exclusiveMinimum": 0}},
+			"properties": {"id": {` + tt.keyword + `}},
+			"additionalProperties": false
+		}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.keyword, err)
+		}
+		if got := v.Validate([]byte(tt.valid)); len(got) != 0 {
+			t.Errorf("%s: %s got violations %q", tt.keyword, tt.valid, got)
+		}
+		got := v.Validate([]byte(tt.invalid))
+		if len(got) == 0 || slices.ContainsFunc(got, func(v string) bool { return !strings.HasPrefix(v, "$.id: ") }) {
+			t.Errorf("%s: %s got violations %q, want them at $.id", tt.keyword, tt.invalid, got)
+		}
+		if got := v.Validate([]byte(`{"extra":1}`)); len(got) != 1 {
+			t.Errorf("%s: additionalProperties got violations %q, want one", tt.keyword, got)
+		}
+	}
+}
+
+func TestNewSchemaValidatorRejectsExternalRefs(t *testing.T) {
+	for _, ref := range []string{"file:///etc/passwd", "https://example.com/schema.json", "other.json"} {
+		if _, err := NewSchemaValidator([]byte(`{"$ref":"` + ref + `"}`)); err == nil {
+			t.Errorf("%s: compiled a schema with an external $ref", ref)
+		}
+	}
+}
+
+func TestSchemaValidatorTypes(t *testing.T) {
+	v, err := NewSchemaValidator([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer"},
+			"title": {"type": ["string", "null"]},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc  string
+		want int
+	}{
+		{`{"id":1,"title":"a","tags":["x"]}`, 0},
+		{`{"id":1.0,"title":null}`, 0},
+		{`{"id":1e2}`, 0},
+		{`{"id":1.5}`, 1},
+		{`{"id":15e-1}`, 1},
+		{`{"title":3}`, 2},
+		{`{"id":1,"tags":["x",2]}`, 1},
+	}
+	for _, tt := range tests {
+		if got := v.Validate([]byte(tt.doc)); len(got) != tt.want {
+			t.Errorf("%s: got violations %q, want %d", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestTodoJSONSchemaMatchesStruct(t *testing.T) {
+	var schema struct {
+		Type       string                       `json:"type"`
+		Properties map[string]map[string]string `json:"properties"`
+		Required   []string                     `json:"required"`
+	}
+	if err := json.Unmarshal(TodoJSONSchema(), &schema); err != nil {
+		t.Fatalf("schema isn't valid JSON: %v", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("schema type is %q, want object", schema.Type)
+	}
+
+	typ := reflect.TypeOf(Todo{})
+	if len(schema.Properties) != typ.NumField() {
+		t.Errorf("schema has %d properties, Todo has %d fields", len(schema.Properties), typ.NumField())
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		prop, ok := schema.Properties[name]
+		if !ok {
+			t.Errorf("field %s (%q) is missing from the schema", field.Name, name)
+			continue
+		}
+		if want := jsonSchemaType(field.Type.Kind()); prop["type"] != want {
+			t.Errorf("property %q has type %q, want %q", name, prop["type"], want)
+		}
+		if !slices.Contains(schema.Required, name) {
+			t.Errorf("property %q isn't required", name)
+		}
+	}
+
+	// The exported schema must be usable with the default validator
+	v, err := NewSchemaValidator(TodoJSONSchema())
+	if err != nil {
+		t.Fatalf("NewSchemaValidator(TodoJSONSchema()): %v", err)
+	}
+	if got := v.Validate([]byte(`{"userId":1,"id":2,"title":"t","completed":false}`)); len(got) != 0 {
+		t.Errorf("a valid todo failed the schema: %q", got)
+	}
+}
+
+// wireCounter counts the response body bytes a client reads off the wire
+type wireCounter struct {
+	next  http.RoundTripper
+	bytes atomic.Int64
+}
+
+func (w *wireCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := w.next.RoundTrip(req)
+	if err == nil {
+		resp.Body = &countedBody{ReadCloser: resp.Body, n: &w.bytes}
+	}
+	return resp, err
+}
+
+// countedBody adds what it reads to n
+type countedBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// withWireCounter installs w around the client's transport
+func withWireCounter(w *wireCounter) Option {
+	return func(c *Client) {
+		c.transports = append(c.transports, func(next http.RoundTripper) http.RoundTripper {
+			w.next = next
+			return w
+		})
+	}
+}
+
+const validatedTodo = `{"userId":1,"id":1,"title":"validated","completed":false}`
+
+func TestSchemaValidationReadsBodyOnce(t *testing.T) {
+	var wire wireCounter
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, validatedTodo)
+	}, WithResponseSchema(TodoJSONSchema()), withWireCounter(&wire))
+
+	todo, err := c.FetchTodo(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("FetchTodo: %v", err)
+	}
+	if todo.Title != "validated" {
+		t.Errorf("decoded title %q from the validated buffer", todo.Title)
+	}
+	if got := wire.bytes.Load(); got != int64(len(validatedTodo)) {
+		t.Errorf("read %d body bytes off the wire, want %d", got, len(validatedTodo))
+	}
+}
+
+// benchmarkFetch fetches a todo b.N times, reporting body bytes read per fetch
+func benchmarkFetch(b *testing.B, opts ...Option) {
+	var wire wireCounter
+	c := newTestClient(b, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, validatedTodo)
+	}, append(opts, withWireCounter(&wire))...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.FetchTodo(context.Background(), 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(wire.bytes.Load())/float64(b.N), "wire-bytes/op")
+}
+
+func BenchmarkFetchTodo(b *testing.B) {
+	benchmarkFetch(b)
+}
+
+func BenchmarkFetchTodoValidated(b *testing.B) {
+	benchmarkFetch(b, WithResponseSchema(TodoJSONSchema()))
+}
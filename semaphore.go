@@ -0,0 +1,114 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Semaphore limits how many holders proceed at once, e.g. to share a
+// concurrency budget between your own goroutines and calls to the client.
+// Waiters are admitted in the order they arrived. It is safe for concurrent use.
+type Semaphore struct {
+	w *weightedSemaphore
+}
+
+// NewSemaphore returns a Semaphore admitting up to n holders at a time
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{w: newWeightedSemaphore(int64(n))}
+}
+
+// Acquire blocks until a slot is free or ctx is done, returning ctx.Err() in
+// the latter case. A context that is already done never acquires.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	return s.w.acquire(ctx, 1)
+}
+
+// Release frees a slot taken by a successful Acquire. Releasing more slots
+// than were acquired panics.
+func (s *Semaphore) Release() {
+	s.w.release(1)
+}
+
+// weightedSemaphore bounds the total weight held at once. Waiters are served in
+// arrival order, so a large request isn't starved by a stream of small ones.
+type weightedSemaphore struct {
+	size int64
+
+	mu      sync.Mutex
+	cur     int64
+	waiters list.List
+}
+
+// semaphoreWaiter is a blocked acquire, woken by closing ready
+type semaphoreWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+func newWeightedSemaphore(size int64) *weightedSemaphore {
+	return &weightedSemaphore{size: size}
+}
+
+// acquire blocks until n can be held or ctx is done. Requests for more than the
+// semaphore's size wait until ctx is done, and nothing is acquired once it is.
+func (s *weightedSemaphore) acquire(ctx context.Context, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+	w := semaphoreWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted just as ctx ended; hand it back
+			s.cur -= n
+		default:
+			s.waiters.Remove(elem)
+		}
+		// Either way the waiters behind this one may now fit
+		s.notify()
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// release gives back n acquired earlier
+func (s *weightedSemaphore) release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore released more than held")
+	}
+	s.notify()
+}
+
+// notify grants waiters in order for as long as they fit. Callers hold s.mu.
+func (s *weightedSemaphore) notify() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
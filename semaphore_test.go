@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreAcquireCancelledWhileWaiting(t *testing.T) {
+	s := NewSemaphore(1)
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Acquire(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Acquire: got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Acquire didn't return after its context was cancelled")
+	}
+
+	// The cancelled waiter mustn't hold or block the slot
+	s.Release()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Acquire(ctx); err != nil {
+		t.Errorf("Acquire after a cancelled waiter: %v", err)
+	}
+}
+
+func TestSemaphoreAcquireWithDoneContext(t *testing.T) {
+	s := NewSemaphore(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire: got %v, want context.Canceled", err)
+	}
+	// Nothing was taken, so the slot is still free
+	if err := s.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSemaphoreCancelledWaiterUnblocksOthers(t *testing.T) {
+	w := newWeightedSemaphore(2)
+	if err := w.acquire(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// A large waiter at the head blocks smaller ones behind it
+	ctx, cancel := context.WithCancel(context.Background())
+	large := make(chan error, 1)
+	go func() { large <- w.acquire(ctx, 2) }()
+	time.Sleep(10 * time.Millisecond)
+	small := make(chan error, 1)
+	go func() { small <- w.acquire(context.Background(), 1) }()
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case <-small:
+		t.Fatal("small acquire jumped the queue")
+	default:
+	}
+	cancel()
+	<-large
+	select {
+	case err := <-small:
+		if err != nil {
+			t.Errorf("small acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancelling the head waiter didn't admit the one behind it")
+	}
+}
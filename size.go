@@ -0,0 +1,30 @@
+package main
+
+import "io"
+
+// SizeRecorder receives the number of body bytes decoded from each successful
+// response, keyed by resource name as used by WithResourceTimeout
+type SizeRecorder func(resource string, bytes int64)
+
+// WithResponseSizeMetric reports every decoded response body's size to record,
+// for single and list fetches alike, so payload sizes can be histogrammed per
+// resource. Bytes are counted as the decoder reads them, after any transfer
+// compression is removed, so the count doesn't depend on Content-Length.
+// record runs on the request's goroutine, so it must return promptly.
+func WithResponseSizeMetric(record SizeRecorder) Option {
+	return func(c *Client) {
+		c.sizeRecorder = record
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
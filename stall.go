@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrStalledTransfer is returned when a response body delivers no data for the
+// period set by WithReadStallTimeout
+var ErrStalledTransfer = errors.New("response transfer stalled")
+
+// WithReadStallTimeout aborts an attempt whose response body goes d without
+// delivering any data, failing it with ErrStalledTransfer. The clock starts
+// when the client starts reading the body, so time spent waiting for
+// WithMaxInFlightBytes budget doesn't count, and restarts with every read
+// that returns data, so a slow but steady transfer runs to the overall
+// deadline while a stalled or trickling connection is dropped early.
+func WithReadStallTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.stallTimeout = d
+	}
+}
+
+// stallReader cancels its attempt when reads stop making progress
+type stallReader struct {
+	ctx    context.Context
+	body   io.ReadCloser
+	d      time.Duration
+	cancel context.CancelCauseFunc
+	// timer is started by the first Read
+	timer *time.Timer
+}
+
+// newStallReader watches body, calling cancel with ErrStalledTransfer once it
+// stalls for d from the first Read. ctx is the attempt's context that cancel
+// ends.
+func newStallReader(ctx context.Context, body io.ReadCloser, d time.Duration, cancel context.CancelCauseFunc) *stallReader {
+	return &stallReader{ctx: ctx, body: body, d: d, cancel: cancel}
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.d, func() { r.cancel(ErrStalledTransfer) })
+	}
+	n, err := r.body.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.d)
+	}
+	if err != nil && err != io.EOF && context.Cause(r.ctx) == ErrStalledTransfer {
+		err = ErrStalledTransfer
+	}
+	return n, err
+}
+
+func (r *stallReader) Close() error {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	return r.body.Close()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReadStallTimeoutAbortsStalledBody(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}, WithReadStallTimeout(20*time.Millisecond))
+
+	_, err := c.FetchTodo(context.Background(), 1)
+	if !errors.Is(err, ErrStalledTransfer) {
+		t.Errorf("got %v, want ErrStalledTransfer", err)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultStreamBuffer is how many results StreamTodos buffers for the consumer
+const defaultStreamBuffer = 16
+
+// WithStreamBuffer sets how many completed results StreamTodos holds for a consumer
+// that hasn't read them yet. Once it's full, workers block until the consumer
+// catches up, so memory stays bounded no matter how slow the consumer is.
+// A size of 0 hands each result directly to the consumer.
+func WithStreamBuffer(size int) Option {
+	return func(c *Client) {
+		if size < 0 {
+			size = 0
+		}
+		c.streamBuffer = size
+	}
+}
+
+// StreamStallPolicy is what a stream does with a result its consumer hasn't
+// taken within the timeout set by WithStreamSendTimeout
+type StreamStallPolicy int
+
+const (
+	// DropStalled logs and drops the result, and the stream carries on
+	DropStalled StreamStallPolicy = iota
+	// AbortStalled logs and stops the stream: outstanding fetches are
+	// abandoned and the channel is closed once they've returned
+	AbortStalled
+)
+
+// errStreamStalled aborts a stream under AbortStalled
+var errStreamStalled = errors.New("stream consumer stopped reading")
+
+// WithStreamSendTimeout bounds how long a stream waits for its consumer to take
+// a result once the buffer is full, so a consumer that stops reading can't
+// hang the workers forever. A result not taken within d is logged and handled
+// per policy. Without it, workers wait until the stream's context is done.
+func WithStreamSendTimeout(d time.Duration, policy StreamStallPolicy) Option {
+	return func(c *Client) {
+		c.sendTimeout = d
+		c.onStalled = policy
+	}
+}
+
+// streamSend delivers result to out. It reports false when the stream should
+// stop: ctx is done, or the consumer stalled and abort was called. With
+// WithStreamDedupe, a successful result is claimed for the window while it's
+// sent, skipped if another stream already emitted it and released again if it
+// isn't delivered.
+func (c *Client) streamSend(ctx context.Context, out chan<- TodoResult, result TodoResult, abort context.CancelCauseFunc) bool {
+	if c.streamDedupe == nil || result.Err != nil {
+		_, more := c.deliver(ctx, out, result, abort)
+		return more
+	}
+	claimed, ok := c.streamDedupe.claim(result.ID)
+	if !ok {
+		return true
+	}
+	sent, more := c.deliver(ctx, out, result, abort)
+	if !sent {
+		c.streamDedupe.unclaim(claimed)
+	}
+	return more
+}
+
+// deliver sends result to out, waiting at most the WithStreamSendTimeout
+// timeout. It reports whether result was sent and whether the stream goes on.
+func (c *Client) deliver(ctx context.Context, out chan<- TodoResult, result TodoResult, abort context.CancelCauseFunc) (sent, more bool) {
+	if c.sendTimeout <= 0 {
+		select {
+		case out <- result:
+			return true, true
+		case <-ctx.Done():
+			return false, false
+		}
+	}
+	timer := time.NewTimer(c.sendTimeout)
+	defer timer.Stop()
+	select {
+	case out <- result:
+		return true, true
+	case <-ctx.Done():
+		return false, false
+	case <-timer.C:
+	}
+	if c.onStalled == AbortStalled {
+		c.logger.Log("stream consumer stalled, aborting stream", "id", result.ID, "timeout", c.sendTimeout)
+		abort(errStreamStalled)
+		return false, false
+	}
+	c.logger.Log("stream consumer stalled, dropping result", "id", result.ID, "timeout", c.sendTimeout)
+	return false, true
+}
+
+// StreamTodos fetches each distinct ID with at most maxConc requests in flight
+// (unbounded when maxConc <= 0) and emits each result as it completes. Workers
+// wait for room in the output channel, applying back-pressure to the fetches,
+// and give up when ctx is done. The channel is closed once every started fetch
+// has finished or been abandoned. See WithStreamSendTimeout for consumers that
+// may stop reading.
+func (c *Client) StreamTodos(ctx context.Context, ids []int, maxConc int) <-chan TodoResult {
+	unique := dedupeIDs(ids)
+	if maxConc <= 0 || maxConc > len(unique) {
+		maxConc = len(unique)
+	}
+	out := make(chan TodoResult, c.streamBuffer)
+
+	go func() {
+		var wg sync.WaitGroup
+		ctx, abort := context.WithCancelCause(ctx)
+		defer close(out)
+		defer abort(nil)
+		defer wg.Wait()
+
+		sem := make(chan struct{}, maxConc)
+		for _, id := range unique {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if result, ok := c.streamResult(ctx, id, "StreamTodos"); ok {
+					c.streamSend(ctx, out, result, abort)
+				}
+			}(id)
+		}
+	}()
+
+	return out
+}
+
+// streamResult fetches one todo for a stream made by the Client method caller.
+// It reports false when WithStreamDedupe says the result shouldn't be emitted.
+func (c *Client) streamResult(ctx context.Context, id int, caller string) (TodoResult, bool) {
+	if c.streamDedupe != nil && c.streamDedupe.recent(id) {
+		return TodoResult{}, false
+	}
+	if err := sleepContext(ctx, c.startDelay()); err != nil {
+		return TodoResult{Result: Result[Todo]{ID: id, Err: newCancellationError(ctx, err)}}, true
+	}
+	result := TodoResult{Result: fetchResult[Todo](ctx, c, "todos", id, caller)}
+	if deadline, ok := ctx.Deadline(); ok {
+		result.DeadlineSlack = time.Until(deadline)
+	}
+	// streamSend claims the ID once the result is ready to go out
+	return result, true
+}
+
+// StreamTodosOrdered is StreamTodos emitting results in input order. Fetches
+// still run concurrently, and completions that arrive ahead of their turn are
+// held until the results before them have been emitted. Held and in-flight
+// fetches together never exceed maxConc, which bounds the reorder buffer: a
+// slow fetch at the head stalls new fetches rather than letting completions
+// pile up.
+func (c *Client) StreamTodosOrdered(ctx context.Context, ids []int, maxConc int) <-chan TodoResult {
+	unique := dedupeIDs(ids)
+	if maxConc <= 0 || maxConc > len(unique) {
+		maxConc = len(unique)
+	}
+	out := make(chan TodoResult, c.streamBuffer)
+
+	go func() {
+		var wg sync.WaitGroup
+		ctx, abort := context.WithCancelCause(ctx)
+		defer close(out)
+		defer abort(nil)
+		defer wg.Wait()
+
+		// Each started fetch gets a slot, queued in input order
+		sem := make(chan struct{}, maxConc)
+		slots := make(chan chan TodoResult, maxConc)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(slots)
+			for _, id := range unique {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+
+				slot := make(chan TodoResult, 1)
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					// A slot closed without a result is skipped
+					defer close(slot)
+					if result, ok := c.streamResult(ctx, id, "StreamTodosOrdered"); ok {
+						slot <- result
+					}
+				}(id)
+				// sem guarantees room for the slot
+				slots <- slot
+			}
+		}()
+
+		for slot := range slots {
+			var result TodoResult
+			var ok bool
+			select {
+			case result, ok = <-slot:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				<-sem
+				continue
+			}
+			if !c.streamSend(ctx, out, result, abort) {
+				return
+			}
+			<-sem
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func todoHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, `{"id":1,"title":"streamed"}`)
+}
+
+// collect reads every result from ch
+func collect(ch <-chan TodoResult) []TodoResult {
+	var results []TodoResult
+	for r := range ch {
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestStreamDedupeSkipsRecentIDs(t *testing.T) {
+	c := newTestClient(t, todoHandler, WithStreamDedupe(time.Minute))
+	if got := collect(c.StreamTodos(context.Background(), []int{1}, 1)); len(got) != 1 {
+		t.Fatalf("first stream emitted %d results, want 1", len(got))
+	}
+	if got := collect(c.StreamTodos(context.Background(), []int{1}, 1)); len(got) != 0 {
+		t.Errorf("second stream emitted %d results, want the ID skipped", len(got))
+	}
+}
+
+func TestStreamDedupeForgetsDroppedResults(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		c := newTestClient(t, todoHandler,
+			WithStreamDedupe(time.Minute),
+			WithStreamBuffer(0),
+			WithStreamSendTimeout(10*time.Millisecond, DropStalled),
+		)
+		stream := c.StreamTodos
+		if ordered {
+			stream = c.StreamTodosOrdered
+		}
+
+		// Nobody reads until the result has been dropped
+		ch := stream(context.Background(), []int{1}, 1)
+		time.Sleep(100 * time.Millisecond)
+		if got := collect(ch); len(got) != 0 {
+			t.Fatalf("ordered=%v: stalled stream delivered %d results, want the result dropped", ordered, len(got))
+		}
+		if got := collect(stream(context.Background(), []int{1}, 1)); len(got) != 1 {
+			t.Errorf("ordered=%v: got %d results after a dropped send, want the todo emitted", ordered, len(got))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// tapQueueSize bounds the todos waiting for a slow response tap
+const tapQueueSize = 256
+
+// tapEvent is one decoded todo waiting to be handed to the response tap
+type tapEvent struct {
+	ctx  context.Context
+	todo Todo
+}
+
+// WithResponseTap hands a copy of every successfully decoded todo to fn on a
+// background goroutine, for side channels such as analytics. Requests never
+// wait for fn: up to 256 todos are queued for it and further ones are dropped,
+// with a log line, until it catches up. fn gets the request's context values
+// without its cancellation, and a panic in fn is logged rather than fatal.
+// The tap stops when the client is closed.
+func WithResponseTap(fn func(ctx context.Context, t *Todo)) Option {
+	return func(c *Client) {
+		c.tap = fn
+	}
+}
+
+// startResponseTap runs the tap worker for the lifetime of the client
+func (c *Client) startResponseTap() {
+	if c.tap == nil {
+		return
+	}
+	c.tapQueue = make(chan tapEvent, tapQueueSize)
+	c.tapStop = make(chan struct{})
+	go func() {
+		for {
+			select {
+			case ev := <-c.tapQueue:
+				c.runTap(ev)
+			case <-c.tapStop:
+				return
+			}
+		}
+	}()
+}
+
+// runTap calls the tap for ev, containing any panic
+func (c *Client) runTap(ev tapEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Log("response tap panicked", "id", ev.todo.ID, "error", fmt.Sprint(r))
+		}
+	}()
+	c.tap(ev.ctx, &ev.todo)
+}
+
+// tapTodo queues todo for the response tap without blocking
+func (c *Client) tapTodo(ctx context.Context, todo *Todo) {
+	if c.tapQueue == nil {
+		return
+	}
+	select {
+	case c.tapQueue <- tapEvent{ctx: context.WithoutCancel(ctx), todo: *todo}:
+	default:
+		c.logger.Log("response tap queue full, dropping todo", "id", todo.ID)
+	}
+}
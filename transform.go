@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// WithResponseTransformer registers fn to post-process every decoded value of type
+// T, e.g. WithResponseTransformer(func(t *Todo) *Todo { ... }). It runs before
+// the value is returned, for single, list, create and update responses alike.
+// A nil fn is a no-op, and a nil result leaves the value unchanged.
+func WithResponseTransformer[T any](fn func(*T) *T) Option {
+	return func(c *Client) {
+		if fn == nil {
+			return
+		}
+		if c.transformers == nil {
+			c.transformers = make(map[reflect.Type]any)
+		}
+		c.transformers[typeOf[T]()] = fn
+	}
+}
+
+// WithResponseTransformers appends fns to a chain of transformers applied to
+// every decoded todo, on the same responses as WithResponseTransformer. They
+// run one after another in the order given, across calls in option order,
+// each receiving the previous one's result; a nil result passes the todo on
+// unchanged. The chain runs after any WithResponseTransformer and before any
+// WithResponseHook. The first error stops the chain and fails the call with
+// it, and a list call then returns no todos.
+func WithResponseTransformers(fns ...func(*Todo) (*Todo, error)) Option {
+	return func(c *Client) {
+		for _, fn := range fns {
+			if fn != nil {
+				c.transformChain = append(c.transformChain, fn)
+			}
+		}
+	}
+}
+
+// WithResponseHook registers fn to rewrite every decoded value of type T using the
+// request's context, e.g. to localize titles for a locale carried in ctx. It
+// runs after any response transformer, on the same responses. A nil fn is a
+// no-op.
+func WithResponseHook[T any](fn func(ctx context.Context, v T) T) Option {
+	return func(c *Client) {
+		if fn == nil {
+			return
+		}
+		if c.responseHooks == nil {
+			c.responseHooks = make(map[reflect.Type]any)
+		}
+		c.responseHooks[typeOf[T]()] = fn
+	}
+}
+
+// typeOf returns the reflect.Type of T
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// transform applies the transformer, transformer chain and hook registered for
+// T, if any, to v, then hands todos to the response tap
+func transform[T any](ctx context.Context, c *Client, v *T) (*T, error) {
+	if v == nil {
+		return v, nil
+	}
+	if fn, ok := c.transformers[typeOf[T]()].(func(*T) *T); ok {
+		if out := fn(v); out != nil {
+			v = out
+		}
+	}
+	if todo, ok := any(v).(*Todo); ok {
+		for _, fn := range c.transformChain {
+			out, err := fn(todo)
+			if err != nil {
+				return nil, err
+			}
+			if out != nil {
+				todo = out
+			}
+		}
+		v = any(todo).(*T)
+	}
+	if fn, ok := c.responseHooks[typeOf[T]()].(func(context.Context, T) T); ok {
+		out := fn(ctx, *v)
+		v = &out
+	}
+	if todo, ok := any(v).(*Todo); ok {
+		c.tapTodo(ctx, todo)
+	}
+	return v, nil
+}
+
+// transformEach applies transform to every element of items, stopping at the
+// first error
+func transformEach[T any](ctx context.Context, c *Client, items []T) error {
+	_, hasTransformer := c.transformers[typeOf[T]()]
+	_, hasHook := c.responseHooks[typeOf[T]()]
+	_, isTodo := any(items).([]Todo)
+	if !hasTransformer && !hasHook && (!isTodo || (c.tapQueue == nil && len(c.transformChain) == 0)) {
+		return nil
+	}
+	for i := range items {
+		v, err := transform(ctx, c, &items[i])
+		if err != nil {
+			return fmt.Errorf("error transforming element %d: %w", i, err)
+		}
+		items[i] = *v
+	}
+	return nil
+}
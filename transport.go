@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// buildTransport applies transport settings and RoundTripper wrappers from the
+// options to a copy of the HTTP client, so a caller-supplied one isn't modified
+func (c *Client) buildTransport() {
+	if len(c.transportConfig) == 0 && len(c.transports) == 0 {
+		return
+	}
+	hc := *c.httpClient
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	// Transport settings only apply to a standard *http.Transport
+	if t, ok := rt.(*http.Transport); ok && len(c.transportConfig) > 0 {
+		t = t.Clone()
+		for _, configure := range c.transportConfig {
+			configure(t)
+		}
+		rt = t
+	}
+
+	for _, wrap := range c.transports {
+		rt = wrap(rt)
+	}
+	hc.Transport = rt
+	c.httpClient = &hc
+}
+
+// WithForceHTTP2 restricts the transport to HTTP/2, including unencrypted HTTP/2
+// with prior knowledge for http:// URLs. Requests fail against servers or
+// proxies that only speak HTTP/1.1, so only use it when the backend is known
+// to support HTTP/2. Has no effect when WithHTTPClient supplies a transport
+// that isn't an *http.Transport.
+func WithForceHTTP2() Option {
+	return func(c *Client) {
+		c.transportConfig = append(c.transportConfig, func(t *http.Transport) {
+			var protocols http.Protocols
+			protocols.SetHTTP2(true)
+			protocols.SetUnencryptedHTTP2(true)
+			t.Protocols = &protocols
+			t.TLSNextProto = nil
+		})
+	}
+}
+
+// WithForceHTTP1 disables HTTP/2 so every request uses HTTP/1.1. This gives up
+// multiplexing, so concurrent requests need more connections, but avoids
+// proxies that mishandle HTTP/2. Has no effect when WithHTTPClient supplies a
+// transport that isn't an *http.Transport.
+func WithForceHTTP1() Option {
+	return func(c *Client) {
+		c.transportConfig = append(c.transportConfig, func(t *http.Transport) {
+			t.Protocols = nil
+			t.ForceAttemptHTTP2 = false
+			// A non-nil empty map stops the transport negotiating h2 over TLS
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		})
+	}
+}
+
+// netDialer returns the dialer used for new connections, creating it with the
+// same settings as http.DefaultTransport the first time a dial option needs it
+func (c *Client) netDialer() *net.Dialer {
+	if c.dialer == nil {
+		c.dialer = &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		dialer := c.dialer
+		c.transportConfig = append(c.transportConfig, func(t *http.Transport) {
+			t.DialContext = dialer.DialContext
+		})
+	}
+	return c.dialer
+}
+
+// WithDialTimeout bounds how long establishing a TCP connection may take, so
+// unreachable hosts fail fast even when the request deadline is far away.
+// When the request context's deadline is sooner, it still applies: the dial
+// gives up at whichever comes first.
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.netDialer().Timeout = d
+	}
+}
+
+// WithKeepAliveInterval sets how often TCP keep-alive probes are sent on idle
+// connections, so connections silently dropped by a NAT or firewall are
+// detected and evicted from the idle pool instead of failing the next request.
+// Unset, Go's standard 30s interval is used; a negative d disables keep-alives.
+func WithKeepAliveInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.netDialer().KeepAlive = d
+	}
+}
+
+// WithResolver looks up hostnames with r instead of the system resolver, e.g. to
+// use a specific DNS server or pin names to fixed addresses. It works by
+// installing a custom DialContext on the transport, so like the other dial
+// options it has no effect when WithHTTPClient supplies a transport that
+// isn't an *http.Transport.
+func WithResolver(r *net.Resolver) Option {
+	return func(c *Client) {
+		c.netDialer().Resolver = r
+	}
+}
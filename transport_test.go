@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeResolver returns a resolver answering every A query with 127.0.0.1 and
+// every other query with no records. Until ready reports true, lookups fail as
+// if the DNS server weren't up yet; a nil ready means it always is.
+func fakeResolver(ready func() bool) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if ready != nil && !ready() {
+				return nil, errors.New("dns server not ready")
+			}
+			client, server := net.Pipe()
+			go serveFakeDNS(server)
+			return client, nil
+		},
+	}
+}
+
+// serveFakeDNS answers queries on conn, framed as DNS over TCP since a
+// net.Pipe isn't a PacketConn
+func serveFakeDNS(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var size uint16
+		if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return
+		}
+		query := make([]byte, size)
+		if _, err := io.ReadFull(conn, query); err != nil || len(query) < 12 {
+			return
+		}
+		// The question name runs from offset 12 to a zero-length label
+		end := 12
+		for end < len(query) && query[end] != 0 {
+			end += int(query[end]) + 1
+		}
+		end += 5 // the terminating zero, then QTYPE and QCLASS
+		if end > len(query) {
+			return
+		}
+		qtype := binary.BigEndian.Uint16(query[end-4:])
+
+		resp := append([]byte(nil), query[:end]...)
+		binary.BigEndian.PutUint16(resp[2:], 0x8180) // a response, recursion available
+		binary.BigEndian.PutUint16(resp[8:], 0)      // no authority records
+		binary.BigEndian.PutUint16(resp[10:], 0)     // no additional records
+		if qtype == 1 {
+			binary.BigEndian.PutUint16(resp[6:], 1)
+			resp = append(resp,
+				0xc0, 12, // the name, as a pointer to the question
+				0, 1, 0, 1, // A, IN
+				0, 0, 0, 60, // TTL
+				0, 4, 127, 0, 0, 1,
+			)
+		} else {
+			binary.BigEndian.PutUint16(resp[6:], 0)
+		}
+		frame := binary.BigEndian.AppendUint16(nil, uint16(len(resp)))
+		if _, err := conn.Write(append(frame, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// hostBaseURL returns a base URL for the test server at serverURL under a
+// made-up hostname only the fake resolver knows
+func hostBaseURL(t *testing.T, serverURL string) string {
+	t.Helper()
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "http://todos.fake.test:" + u.Port()
+}
+
+func TestWithResolverMapsHostToLocalhost(t *testing.T) {
+	srv := newTestClient(t, todoHandler)
+	c, err := NewClient(WithBaseURL(hostBaseURL(t, srv.baseURL)), WithResolver(fakeResolver(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	todo, err := c.FetchTodo(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("FetchTodo through the fake resolver: %v", err)
+	}
+	if todo.ID != 1 {
+		t.Errorf("got todo %d, want 1", todo.ID)
+	}
+}
+
+func TestTransientDNSFailureIsRetried(t *testing.T) {
+	srv := newTestClient(t, todoHandler)
+	var ready atomic.Bool
+	var firstErr error
+	c, err := NewClient(
+		WithBaseURL(hostBaseURL(t, srv.baseURL)),
+		WithResolver(fakeResolver(ready.Load)),
+		WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+		// The resolver comes up while the client backs off
+		WithOnRetry(func(attempt int, err error, nextDelay time.Duration) {
+			if attempt == 1 {
+				firstErr = err
+			}
+			ready.Store(true)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	res := c.FetchTodoWithResult(context.Background(), 1)
+	if res.Err != nil {
+		t.Fatalf("FetchTodo once DNS was ready: %v", res.Err)
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(firstErr, &dnsErr) {
+		t.Errorf("first attempt failed with %v, want a *net.DNSError", firstErr)
+	}
+	if res.Attempts != 2 {
+		t.Errorf("took %d attempts, want 2", res.Attempts)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidConfig is wrapped by the error NewClient returns for options that
+// make no sense together or on their own
+var ErrInvalidConfig = errors.New("invalid client configuration")
+
+// validate checks the configuration left by the options, returning every
+// problem found, each naming the option responsible
+func (c *Client) validate() error {
+	var problems []error
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.schemaErr != nil {
+		add("WithResponseSchema: %w", c.schemaErr)
+	}
+	if c.specErr != nil {
+		add("WithOpenAPISpec: %w", c.specErr)
+	}
+	if err := checkBaseURL(c.baseURL); err != nil {
+		add("WithBaseURL: %w", err)
+	}
+	for _, u := range c.fallbackBaseURLs {
+		if err := checkBaseURL(u); err != nil {
+			add("WithFallbackBaseURLs: %w", err)
+		}
+	}
+	if c.language != "" {
+		if err := checkLanguage(c.language); err != nil {
+			add("WithLanguage: %w", err)
+		}
+	}
+	if c.httpClient == nil {
+		add("WithHTTPClient: nil client")
+	}
+
+	if c.timeout < 0 {
+		add("WithTimeout: negative timeout %v", c.timeout)
+	}
+	resources := make([]string, 0, len(c.resourceTimeouts))
+	for resource := range c.resourceTimeouts {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	for _, resource := range resources {
+		if d := c.resourceTimeouts[resource]; d < 0 {
+			add("WithResourceTimeout: negative timeout %v for %q", d, resource)
+		}
+	}
+	methods := make([]string, 0, len(c.methodTimeouts))
+	for method := range c.methodTimeouts {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		if d := c.methodTimeouts[method]; d < 0 {
+			add("WithMethodTimeout: negative timeout %v for %q", d, method)
+		}
+		if _, ok := reflect.TypeOf(c).MethodByName(method); !ok {
+			add("WithMethodTimeout: Client has no method %q", method)
+		}
+	}
+	if c.minLatency < 0 {
+		add("WithMinLatency: negative latency %v", c.minLatency)
+	}
+	if c.maxTotalDuration < 0 {
+		add("WithMaxTotalDuration: negative duration %v", c.maxTotalDuration)
+	}
+	if c.shutdownGrace < 0 {
+		add("WithShutdownGrace: negative grace period %v", c.shutdownGrace)
+	}
+	if c.deadlineGrace < 0 {
+		add("WithDeadlineGrace: negative grace period %v", c.deadlineGrace)
+	}
+	if c.slowThreshold < 0 {
+		add("WithSlowThreshold: negative threshold %v", c.slowThreshold)
+	}
+	if c.stallTimeout < 0 {
+		add("WithReadStallTimeout: negative timeout %v", c.stallTimeout)
+	}
+	if c.sendTimeout < 0 {
+		add("WithStreamSendTimeout: negative timeout %v", c.sendTimeout)
+	}
+	if c.startupJitter < 0 {
+		add("WithStartupJitter: negative jitter %v", c.startupJitter)
+	}
+	if c.dialer != nil && c.dialer.Timeout < 0 {
+		add("WithDialTimeout: negative timeout %v", c.dialer.Timeout)
+	}
+
+	if c.retry.MaxAttempts < 0 {
+		add("WithRetry: negative MaxAttempts %d", c.retry.MaxAttempts)
+	}
+	if c.retry.BaseDelay < 0 || c.retry.MaxDelay < 0 {
+		add("WithRetry: negative delay")
+	}
+	if c.retry.MaxDelay > 0 && c.retry.MaxDelay < c.retry.BaseDelay {
+		add("WithRetry: MaxDelay %v is below BaseDelay %v", c.retry.MaxDelay, c.retry.BaseDelay)
+	}
+	for status, n := range c.retryByStatus {
+		if n < 1 {
+			add("WithRetryByStatus: %d attempts for status %d, want at least 1", n, status)
+		}
+	}
+
+	for resource, sem := range c.bulkheads {
+		if sem.size < 1 {
+			add("WithBulkhead: limit %d for %q would block every call", sem.size, resource)
+		}
+	}
+	if c.bodyBudget != nil && c.bodyBudget.size < 1 {
+		add("WithMaxInFlightBytes: budget %d would block every response", c.bodyBudget.size)
+	}
+	if c.logSampleRate < 0 || math.IsNaN(c.logSampleRate) {
+		add("WithLogSampling: rate %v is not between 0 and 1", c.logSampleRate)
+	}
+	if c.faultRate < 0 || c.faultRate > 1 || math.IsNaN(c.faultRate) {
+		add("WithFailureInjection: rate %v is not between 0 and 1", c.faultRate)
+	}
+	if c.faultRate > 0 && c.faultErr == nil {
+		add("WithFailureInjection: nil error")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(problems...))
+}
+
+// checkBaseURL reports whether rawURL is a usable absolute HTTP(S) URL
+func checkBaseURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Errorf("base URL %q must be http or https", rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("base URL %q has no host", rawURL)
+	}
+	return nil
+}
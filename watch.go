@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// defaultWatchInterval is how often WatchTodos polls when given no interval
+const defaultWatchInterval = 30 * time.Second
+
+// WatchTodos polls the full todo list every pollInterval (30s if it isn't
+// positive) and emits it, sorted by ID, whenever it differs from the last list
+// emitted; the first successful poll is always emitted. Failed polls are
+// logged and retried at the next interval. The channel is closed once ctx is
+// done.
+func (c *Client) WatchTodos(ctx context.Context, pollInterval time.Duration) <-chan []Todo {
+	return c.watchTodos(ctx, pollInterval, "WatchTodos")
+}
+
+// watchTodos is WatchTodos for the Client method caller
+func (c *Client) watchTodos(ctx context.Context, pollInterval time.Duration, caller string) <-chan []Todo {
+	if pollInterval <= 0 {
+		pollInterval = defaultWatchInterval
+	}
+	out := make(chan []Todo)
+
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		var last []Todo
+		emitted := false
+		for {
+			todos, err := c.fetchAllTodos(ctx, nil, caller)
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err != nil:
+				c.logger.Log("watch poll failed", "error", err)
+			default:
+				slices.SortFunc(todos, func(a, b Todo) int { return a.ID - b.ID })
+				if !emitted || !slices.Equal(todos, last) {
+					select {
+					case out <- todos:
+					case <-ctx.Done():
+						return
+					}
+					// Keep a copy in case the consumer modifies the slice
+					last, emitted = slices.Clone(todos), true
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// TodoChangeSet is what changed in the todo list between two polls. Updated
+// holds the new versions of todos whose fields changed.
+type TodoChangeSet struct {
+	Added   []Todo
+	Updated []Todo
+	Removed []Todo
+}
+
+// WatchTodoChanges is WatchTodos emitting only what changed since the previous
+// poll, with todos matched by ID and compared with DiffTodos. The first change
+// set lists every todo as added.
+func (c *Client) WatchTodoChanges(ctx context.Context, pollInterval time.Duration) <-chan TodoChangeSet {
+	out := make(chan TodoChangeSet)
+
+	go func() {
+		defer close(out)
+		var previous []Todo
+		for todos := range c.watchTodos(ctx, pollInterval, "WatchTodoChanges") {
+			changes := diffTodoLists(previous, todos)
+			previous = todos
+			if len(changes.Added)+len(changes.Updated)+len(changes.Removed) == 0 {
+				continue
+			}
+			select {
+			case out <- changes:
+			case <-ctx.Done():
+				// WatchTodos closes its channel once ctx is done
+			}
+		}
+	}()
+
+	return out
+}
+
+// diffTodoLists compares two todo lists keyed by ID, both sorted by ID
+func diffTodoLists(before, after []Todo) TodoChangeSet {
+	var changes TodoChangeSet
+	byID := make(map[int]Todo, len(before))
+	for _, todo := range before {
+		byID[todo.ID] = todo
+	}
+	for _, todo := range after {
+		prev, ok := byID[todo.ID]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, todo)
+		case len(DiffTodos(prev, todo)) > 0:
+			changes.Updated = append(changes.Updated, todo)
+		}
+		delete(byID, todo.ID)
+	}
+	// Whatever is left wasn't in the new list; keep the removals in ID order
+	for _, todo := range before {
+		if _, ok := byID[todo.ID]; ok {
+			changes.Removed = append(changes.Removed, todo)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// defaultRedactedHeaders are masked in wire logs unless WithWireLogRedaction says otherwise
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization"}
+
+// WithWireLog dumps every request and response, bodies included, to w. The
+// Authorization and Proxy-Authorization headers are masked by default; use
+// WithWireLogRedaction to choose which headers are masked.
+func WithWireLog(w io.Writer) Option {
+	return func(c *Client) {
+		c.transports = append(c.transports, func(next http.RoundTripper) http.RoundTripper {
+			// Options have all been applied by the time transports are built
+			redact := c.wireRedact
+			if redact == nil {
+				redact = defaultRedactedHeaders
+			}
+			return &wireLogTransport{next: next, w: w, redact: redact}
+		})
+	}
+}
+
+// WithWireLogRedaction sets the headers masked in wire logs, replacing the
+// defaults; calling it with no headers disables redaction
+func WithWireLogRedaction(headers ...string) Option {
+	return func(c *Client) {
+		c.wireRedact = append([]string{}, headers...)
+	}
+}
+
+// wireLogTransport writes a dump of each exchange to w
+type wireLogTransport struct {
+	next   http.RoundTripper
+	w      io.Writer
+	redact []string
+
+	mu sync.Mutex
+}
+
+func (t *wireLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("wire log: error reading request body: %w", err)
+	}
+	logged := req.Clone(req.Context())
+	logged.Header = t.redacted(req.Header)
+	if body != nil {
+		logged.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if dump, err := httputil.DumpRequestOut(logged, true); err == nil {
+		t.write(dump)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.write([]byte(fmt.Sprintf("<< %s %s failed: %v\n", req.Method, req.URL, err)))
+		return nil, err
+	}
+
+	loggedResp := *resp
+	loggedResp.Header = t.redacted(resp.Header)
+	dump, err := httputil.DumpResponse(&loggedResp, true)
+	// DumpResponse replaces the body it read with an equivalent one
+	resp.Body = loggedResp.Body
+	if err == nil {
+		t.write(dump)
+	}
+	return resp, nil
+}
+
+// redacted returns a copy of h with the configured headers masked
+func (t *wireLogTransport) redacted(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range t.redact {
+		if h.Get(name) != "" {
+			h.Set(name, "REDACTED")
+		}
+	}
+	return h
+}
+
+// write appends one dump to the log, keeping concurrent dumps from interleaving
+func (t *wireLogTransport) write(dump []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(dump)
+	t.w.Write([]byte("\n\n"))
+}